@@ -2,14 +2,50 @@ package clock
 
 import "time"
 
-// Clock abstracts time for deterministic tests.
+// Clock abstracts time for deterministic tests. Beyond Now, it exposes the
+// same primitives time itself does (After, NewTimer, Sleep) so that code
+// which waits on time -- not just reads it -- can be driven by a FakeClock.
 type Clock interface {
 	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	Sleep(d time.Duration)
 }
 
+// Timer mirrors the parts of *time.Timer callers need: a channel that fires
+// once, and the ability to stop it early.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// RealClock implements Clock using the system clock and the time package.
 type RealClock struct{}
 
 // Now returns the current time using the system clock.
 func (RealClock) Now() time.Time {
 	return time.Now()
 }
+
+// After returns a channel that delivers the current time after d elapses.
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTimer starts a timer that fires once after d elapses.
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// Sleep blocks the calling goroutine for d.
+func (RealClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// realTimer adapts *time.Timer's C field to the Timer interface.
+type realTimer struct {
+	t *time.Timer
+}
+
+func (r realTimer) C() <-chan time.Time { return r.t.C }
+func (r realTimer) Stop() bool          { return r.t.Stop() }
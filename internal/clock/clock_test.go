@@ -1,26 +1,11 @@
 package clock
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
 
-type FakeClock struct {
-	now time.Time
-}
-
-func NewFakeClock(start time.Time) *FakeClock {
-	return &FakeClock{now: start}
-}
-
-func (f *FakeClock) Now() time.Time {
-	return f.now
-}
-
-func (f *FakeClock) Advance(d time.Duration) {
-	f.now = f.now.Add(d)
-}
-
 func TestRealClockNow(t *testing.T) {
 	clk := RealClock{}
 	if clk.Now().IsZero() {
@@ -42,3 +27,87 @@ func TestFakeClockAdvance(t *testing.T) {
 		t.Fatalf("expected %v got %v", want, clk.Now())
 	}
 }
+
+func TestFakeClockAfterFiresOnAdvance(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFakeClock(start)
+
+	ch := clk.After(5 * time.Second)
+
+	select {
+	case <-ch:
+		t.Fatalf("expected After to not fire before Advance")
+	default:
+	}
+
+	clk.BlockUntil(1)
+	clk.Advance(5 * time.Second)
+
+	select {
+	case got := <-ch:
+		if !got.Equal(start.Add(5 * time.Second)) {
+			t.Fatalf("expected delivered time %v got %v", start.Add(5*time.Second), got)
+		}
+	default:
+		t.Fatalf("expected After to fire after Advance")
+	}
+}
+
+func TestFakeClockSleepBlocksUntilAdvance(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFakeClock(start)
+
+	done := make(chan struct{})
+	go func() {
+		clk.Sleep(2 * time.Second)
+		close(done)
+	}()
+
+	clk.BlockUntil(1)
+	clk.Advance(2 * time.Second)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected Sleep to unblock after Advance")
+	}
+}
+
+func TestFakeClockTimerStopPreventsFiring(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFakeClock(start)
+
+	timer := clk.NewTimer(time.Second)
+	clk.BlockUntil(1)
+
+	if !timer.Stop() {
+		t.Fatalf("expected Stop to report true before firing")
+	}
+
+	clk.Advance(time.Second)
+
+	select {
+	case <-timer.C():
+		t.Fatalf("expected stopped timer not to fire")
+	default:
+	}
+}
+
+func TestFakeClockBlockUntilWaitsForWaiters(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFakeClock(start)
+
+	var wg sync.WaitGroup
+	const waiters = 3
+	wg.Add(waiters)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			defer wg.Done()
+			clk.Sleep(time.Second)
+		}()
+	}
+
+	clk.BlockUntil(waiters)
+	clk.Advance(time.Second)
+	wg.Wait()
+}
@@ -0,0 +1,120 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeWaiter is a single parked After/NewTimer/Sleep call.
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// FakeClock is a Clock whose time only advances when Advance is called. It
+// tracks every goroutine parked in After, NewTimer, or Sleep so tests can use
+// BlockUntil to wait for a background goroutine to reach a known point
+// before advancing time and asserting on the result, instead of racing a
+// real time.Sleep.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// NewFakeClock returns a FakeClock starting at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	f := &FakeClock{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the clock's current time.
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance moves the clock forward by d, delivering on every waiter whose
+// deadline has now passed.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		if !w.deadline.After(f.now) {
+			w.ch <- f.now
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	f.waiters = remaining
+	f.cond.Broadcast()
+}
+
+// BlockUntil blocks the calling goroutine until at least n goroutines are
+// parked on this clock's After/NewTimer/Sleep.
+func (f *FakeClock) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// After returns a channel that delivers the clock's time once it has
+// advanced d past its value when After was called.
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	return f.addWaiter(d).ch
+}
+
+// Sleep blocks the calling goroutine until the clock advances by d.
+func (f *FakeClock) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// NewTimer starts a fake timer that fires once the clock has advanced d past
+// its value when NewTimer was called.
+func (f *FakeClock) NewTimer(d time.Duration) Timer {
+	return &fakeTimer{clock: f, waiter: f.addWaiter(d)}
+}
+
+func (f *FakeClock) addWaiter(d time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{deadline: f.now.Add(d), ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	return w
+}
+
+// fakeTimer implements Timer against a FakeClock's waiter list.
+type fakeTimer struct {
+	clock  *FakeClock
+	waiter *fakeWaiter
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.waiter.ch }
+
+// Stop removes the timer from its clock's waiter list if it hasn't fired
+// yet, reporting whether it did so.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, w := range t.clock.waiters {
+		if w == t.waiter {
+			t.clock.waiters = append(t.clock.waiters[:i], t.clock.waiters[i+1:]...)
+			t.clock.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
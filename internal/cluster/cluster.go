@@ -0,0 +1,235 @@
+// Package cluster replicates a service.GameService across a Raft cluster so
+// the game can run with a single leader and crash-tolerant followers.
+package cluster
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"scraps/internal/clock"
+	"scraps/internal/commands"
+	"scraps/internal/config"
+	"scraps/internal/domain"
+	"scraps/internal/service"
+)
+
+// ErrNotLeader is returned by Propose when called on a non-leader node; the
+// caller should retry against Leader().
+var ErrNotLeader = errors.New("cluster: not the leader")
+
+// proposedCommand is the gob-encoded payload written to the Raft log. Now is
+// the leader's clock reading at propose time; every node applies the command
+// with this exact timestamp so Settle/CraftComponent/etc. stay deterministic
+// regardless of which node's FSM.Apply runs it.
+type proposedCommand struct {
+	Kind      string
+	CommandID string
+	Now       time.Time
+
+	// SlotIndex carries ClaimCraftedComponent/CancelCraft's SlotIndex field,
+	// since commands.FromKind otherwise has no way to tell a replayed command
+	// which craft slot the original targeted.
+	SlotIndex int
+}
+
+// Cluster wraps a service.GameService behind Raft consensus. Commands accepted
+// by Propose become Raft log entries; FSM.Apply dispatches them into the
+// wrapped service identically on every node.
+type Cluster struct {
+	raft  *raft.Raft
+	fsm   *FSM
+	clock clock.Clock
+}
+
+// FSM implements raft.FSM by dispatching committed log entries into a
+// service.GameService.
+type FSM struct {
+	svc *service.GameService
+}
+
+// NewFSM wraps svc so it can be driven by a Raft log.
+func NewFSM(svc *service.GameService) *FSM {
+	return &FSM{svc: svc}
+}
+
+// Apply decodes a proposedCommand from the Raft log and applies it to the
+// wrapped service using the leader's injected timestamp.
+func (f *FSM) Apply(log *raft.Log) interface{} {
+	var pc proposedCommand
+	if err := gob.NewDecoder(bytes.NewReader(log.Data)).Decode(&pc); err != nil {
+		return fmt.Errorf("cluster: decode log entry: %w", err)
+	}
+
+	cmd, err := commands.FromKind(pc.Kind, pc.CommandID, pc.SlotIndex)
+	if err != nil {
+		return err
+	}
+
+	result, err := f.svc.ApplyAt(cmd, pc.Now)
+	return fsmResponse{Result: result, Err: err}
+}
+
+// fsmResponse is returned from Apply and surfaced back to the proposer via
+// raft.ApplyFuture.Response().
+type fsmResponse struct {
+	Result service.Result
+	Err    error
+}
+
+// Snapshot implements raft.FSM using the same state encoding as the
+// event-sourced persistence layer (see service.GameService.Snapshot):
+// State plus EventSequence, so a node restored from this snapshot resumes
+// minting Event.IDs after where the snapshot left off instead of from 0.
+func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
+	state, eventSequence := f.svc.GetStateAndEventSequence()
+	return &fsmSnapshot{state: state, eventSequence: eventSequence}, nil
+}
+
+// Restore implements raft.FSM by decoding a snapshot produced by Snapshot and
+// replacing the wrapped service's state and event sequence with it.
+func (f *FSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	var payload snapshotPayload
+	if err := gob.NewDecoder(rc).Decode(&payload); err != nil {
+		return fmt.Errorf("cluster: decode snapshot: %w", err)
+	}
+	f.svc.RestoreState(payload.State, payload.EventSequence)
+	return nil
+}
+
+type snapshotPayload struct {
+	State         domain.State
+	EventSequence int64
+}
+
+type fsmSnapshot struct {
+	state         domain.State
+	eventSequence int64
+}
+
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	payload := snapshotPayload{State: s.state, EventSequence: s.eventSequence}
+	if err := gob.NewEncoder(sink).Encode(payload); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *fsmSnapshot) Release() {}
+
+// scheduledProposeTimeout bounds how long a leader waits for Raft to commit a
+// scheduled command (e.g. the CraftComponent auto-claim hook) re-proposed by
+// svc's scheduledExecutor; see New.
+const scheduledProposeTimeout = 5 * time.Second
+
+// New builds a Raft-backed Cluster around a freshly created GameService for
+// the given configuration, using raftConfig/logs/stable/snaps/trans exactly
+// as raft.NewRaft expects.
+func New(cfg config.Config, clk clock.Clock, startTime time.Time, raftConfig *raft.Config, logs raft.LogStore, stable raft.StableStore, snaps raft.SnapshotStore, trans raft.Transport) (*Cluster, error) {
+	svc := service.NewGameService(cfg, clk, startTime)
+	fsm := NewFSM(svc)
+
+	c := &Cluster{fsm: fsm, clock: clk}
+
+	// FSM.Apply runs identically on every node, so every node's svc
+	// independently schedules the same job (e.g. the CraftComponent
+	// auto-claim hook). GameService's default scheduledExecutor just applies
+	// a due job locally via Execute, which would let every node mutate its
+	// own state outside of Raft. Only the current leader may actually run a
+	// scheduled job, and only by re-proposing it so the mutation goes
+	// through the replicated log like any other command; a non-leader node
+	// reports ErrSchedulerUnavailable so runScheduler retries later instead
+	// of silently dropping the job.
+	svc.SetScheduledExecutor(func(cmd commands.Command) (service.Result, error) {
+		result, err := c.Propose(cmd, scheduledProposeTimeout)
+		if errors.Is(err, ErrNotLeader) {
+			return service.Result{}, service.ErrSchedulerUnavailable
+		}
+		return result, err
+	})
+
+	r, err := raft.NewRaft(raftConfig, fsm, logs, stable, snaps, trans)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: start raft: %w", err)
+	}
+	c.raft = r
+
+	return c, nil
+}
+
+// Bootstrap initializes a brand-new cluster with the given voter set. Call
+// this exactly once, on exactly one node, before any Propose calls.
+func (c *Cluster) Bootstrap(servers []raft.Server) error {
+	return c.raft.BootstrapCluster(raft.Configuration{Servers: servers}).Error()
+}
+
+// Join adds voter id@addr to the cluster's configuration. Must be called on
+// the current leader.
+func (c *Cluster) Join(id raft.ServerID, addr raft.ServerAddress) error {
+	if c.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+	return c.raft.AddVoter(id, addr, 0, 10*time.Second).Error()
+}
+
+// IsLeader reports whether this node is currently the Raft leader.
+func (c *Cluster) IsLeader() bool {
+	return c.raft.State() == raft.Leader
+}
+
+// Leader returns the address of the current leader, if known.
+func (c *Cluster) Leader() raft.ServerAddress {
+	return c.raft.Leader()
+}
+
+// Propose replicates cmd through Raft and returns the resulting service
+// state once a quorum has committed it and this node has applied it. It returns ErrNotLeader if this node is
+// not currently the leader; callers should redial against Leader() themselves
+// (see rpc.go's Server/Client, which expose this over net/rpc without
+// forwarding non-leader calls).
+func (c *Cluster) Propose(cmd commands.Command, timeout time.Duration) (service.Result, error) {
+	if c.raft.State() != raft.Leader {
+		return service.Result{}, ErrNotLeader
+	}
+
+	pc := proposedCommand{
+		Kind:      cmd.Name(),
+		CommandID: cmd.CommandID(),
+		Now:       c.clock.Now(),
+	}
+	switch typed := cmd.(type) {
+	case *commands.ClaimCraftedComponent:
+		pc.SlotIndex = typed.SlotIndex
+	case commands.CancelCraft:
+		pc.SlotIndex = typed.SlotIndex
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(pc); err != nil {
+		return service.Result{}, fmt.Errorf("cluster: encode command: %w", err)
+	}
+
+	future := c.raft.Apply(buf.Bytes(), timeout)
+	if err := future.Error(); err != nil {
+		return service.Result{}, fmt.Errorf("cluster: apply: %w", err)
+	}
+
+	resp, ok := future.Response().(fsmResponse)
+	if !ok {
+		return service.Result{}, fmt.Errorf("cluster: unexpected FSM response type %T", future.Response())
+	}
+	return resp.Result, resp.Err
+}
+
+// Shutdown gracefully stops this node's participation in the Raft cluster.
+func (c *Cluster) Shutdown() error {
+	return c.raft.Shutdown().Error()
+}
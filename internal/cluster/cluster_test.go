@@ -0,0 +1,222 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"scraps/internal/clock"
+	"scraps/internal/commands"
+	"scraps/internal/config"
+	"scraps/internal/service"
+)
+
+type testNode struct {
+	id      string
+	cluster *Cluster
+	addr    raft.ServerAddress
+	trans   *raft.InmemTransport
+}
+
+// newTestNode starts a node backed by clk, which callers share across every
+// node in a test cluster so that whichever node is leader at propose time
+// stamps commands with the same clock.
+func newTestNode(t *testing.T, id string, cfg config.Config, clk *clock.FakeClock, start time.Time) testNode {
+	t.Helper()
+
+	addr, trans := raft.NewInmemTransport("")
+
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = raft.ServerID(id)
+	raftConfig.HeartbeatTimeout = 50 * time.Millisecond
+	raftConfig.ElectionTimeout = 50 * time.Millisecond
+	raftConfig.LeaderLeaseTimeout = 50 * time.Millisecond
+	raftConfig.CommitTimeout = 5 * time.Millisecond
+
+	c, err := New(cfg, clk, start, raftConfig, raft.NewInmemStore(), raft.NewInmemStore(), raft.NewInmemSnapshotStore(), trans)
+	if err != nil {
+		t.Fatalf("node %s: start: %v", id, err)
+	}
+	return testNode{id: id, cluster: c, addr: addr, trans: trans}
+}
+
+func waitForLeader(t *testing.T, nodes []testNode) int {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for i, n := range nodes {
+			if n.cluster.IsLeader() {
+				return i
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("no leader elected")
+	return -1
+}
+
+// TestThreeNodeClusterSurvivesLeaderLoss starts a 3-node Raft cluster, crafts
+// a component through the leader, kills the leader mid-craft, waits for a new
+// leader, and asserts the craft still yields exactly one component: the
+// craft job itself was already replicated before the leader died, so the new
+// leader has everything it needs to finish the claim. The craft's auto-claim
+// hook is also scheduled on every node (FSM.Apply runs identically
+// everywhere), but only the new leader's own scheduler ever actually fires
+// it, and only by re-proposing the claim through Raft (see
+// GameService.SetScheduledExecutor in New) - so the explicit claim below and
+// the auto-claim hook race to the same replicated log, and either is an
+// equally valid way to observe the result.
+func TestThreeNodeClusterSurvivesLeaderLoss(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+
+	cfg := config.Default()
+	cfg.CraftComponentTechnologyCost = 1
+	cfg.CraftComponentCost = 1
+	cfg.CraftDurationSecs = 5
+
+	ids := []string{"node1", "node2", "node3"}
+	nodes := make([]testNode, len(ids))
+	for i, id := range ids {
+		nodes[i] = newTestNode(t, id, cfg, clk, start)
+	}
+
+	for i := range nodes {
+		for j := range nodes {
+			if i != j {
+				nodes[i].trans.Connect(nodes[j].addr, nodes[j].trans)
+			}
+		}
+	}
+
+	servers := make([]raft.Server, len(nodes))
+	for i, n := range nodes {
+		servers[i] = raft.Server{ID: raft.ServerID(n.id), Address: n.addr}
+	}
+	if err := nodes[0].cluster.Bootstrap(servers); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+
+	leaderIdx := waitForLeader(t, nodes)
+	leader := nodes[leaderIdx].cluster
+
+	clk.Advance(1 * time.Second)
+	if _, err := leader.Propose(commands.UnlockComponentCrafting{CommandIDValue: "unlock-1"}, time.Second); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	// Advance again so Settle mints enough scrap for the craft too: the
+	// unlock above already spent the first second's worth.
+	clk.Advance(1 * time.Second)
+	if _, err := leader.Propose(commands.CraftComponent{CommandIDValue: "craft-1"}, time.Second); err != nil {
+		t.Fatalf("craft: %v", err)
+	}
+
+	if err := leader.Shutdown(); err != nil {
+		t.Fatalf("shutdown leader: %v", err)
+	}
+
+	remaining := make([]testNode, 0, len(nodes)-1)
+	for i, n := range nodes {
+		if i != leaderIdx {
+			remaining = append(remaining, n)
+		}
+	}
+	waitForLeader(t, remaining)
+
+	clk.Advance(time.Duration(cfg.CraftDurationSecs) * time.Second)
+
+	// The CraftComponent above scheduled an auto-claim hook on every node
+	// (FSM.Apply runs identically everywhere), but only the current leader's
+	// own scheduler ever actually fires it, and only by re-proposing the
+	// claim through Raft (see GameService.SetScheduledExecutor in New), so
+	// either this explicit claim or the auto-claim hook can legitimately win
+	// - both go through the same replicated log and are equally valid. Poll
+	// whichever node in remaining currently accepts Propose (leadership may
+	// still flap right after the old leader's Shutdown) and tolerate
+	// ErrNoActiveCraft, which means the auto-claim already got there first.
+	deadline := time.Now().Add(5 * time.Second)
+	var result service.Result
+	claimed := false
+	for i := 0; !claimed && time.Now().Before(deadline); i++ {
+		for _, n := range remaining {
+			r, err := n.cluster.Propose(&commands.ClaimCraftedComponent{CommandIDValue: fmt.Sprintf("claim-check-%d", i)}, time.Second)
+			if err == nil {
+				result = r
+				claimed = true
+				break
+			}
+			if errors.Is(err, service.ErrNoActiveCraft) {
+				r, err := n.cluster.Propose(&commands.Settle{CommandIDValue: fmt.Sprintf("settle-check-%d", i)}, time.Second)
+				if err == nil {
+					result = r
+					claimed = true
+					break
+				}
+			}
+		}
+		if !claimed {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	if !claimed {
+		t.Fatalf("failed to claim or observe claimed craft within deadline")
+	}
+	if result.State.Components != 1 {
+		t.Fatalf("expected exactly 1 component after leader loss, got %d", result.State.Components)
+	}
+}
+
+// TestFSMSnapshotRestoreRoundTripsEventSequence crafts a component (so the
+// wrapped service's event sequence is non-zero), snapshots the FSM, restores
+// it into a second, fresh FSM, and asserts the second FSM resumes from the
+// same event sequence rather than 0 - otherwise it would mint overlapping
+// Event.IDs for every command applied after the restore.
+func TestFSMSnapshotRestoreRoundTripsEventSequence(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+
+	svc := service.NewGameService(config.Default(), clk, start)
+	fsm := NewFSM(svc)
+
+	if _, err := svc.ApplyAt(&commands.Settle{CommandIDValue: "settle-1"}, start); err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+	_, wantSeq := svc.GetStateAndEventSequence()
+	if wantSeq == 0 {
+		t.Fatalf("expected a non-zero event sequence after applying a command")
+	}
+
+	store := raft.NewInmemSnapshotStore()
+	sink, err := store.Create(raft.SnapshotVersionMax, 1, 1, raft.Configuration{}, 0, nil)
+	if err != nil {
+		t.Fatalf("create sink: %v", err)
+	}
+
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if err := snap.Persist(sink); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+
+	_, rc, err := store.Open(sink.ID())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	restoredSvc := service.NewGameService(config.Default(), clk, start)
+	restoredFSM := NewFSM(restoredSvc)
+	if err := restoredFSM.Restore(rc); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	_, gotSeq := restoredSvc.GetStateAndEventSequence()
+	if gotSeq != wantSeq {
+		t.Fatalf("expected restored event sequence %d got %d", wantSeq, gotSeq)
+	}
+}
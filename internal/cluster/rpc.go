@@ -0,0 +1,126 @@
+package cluster
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"time"
+
+	"scraps/internal/commands"
+	"scraps/internal/service"
+)
+
+// ProposeArgs is the net/rpc request for Server.Propose.
+type ProposeArgs struct {
+	Kind      string
+	CommandID string
+
+	// SlotIndex carries ClaimCraftedComponent/CancelCraft's SlotIndex field,
+	// since commands.FromKind otherwise has no way to tell a reconstructed
+	// command which craft slot the original targeted.
+	SlotIndex int
+}
+
+// ProposeReply is the net/rpc response for Server.Propose.
+type ProposeReply struct {
+	Result service.Result
+	Err    string
+
+	// Leader is this node's view of the current Raft leader's address (see
+	// Cluster.Leader), set whenever Err is ErrNotLeader's text. It is a Raft
+	// transport address, not necessarily one Client can Dial - nothing in
+	// this package maps one to the other - so redialing against it, if
+	// possible at all, is left to the caller.
+	Leader string
+}
+
+// Server exposes a Cluster over net/rpc so clients can issue commands to
+// whichever node they happen to connect to. It does not forward a call made
+// against a non-leader node: Propose just reports ErrNotLeader plus its view
+// of the leader's address (see ProposeReply.Leader) and leaves redialing to
+// the caller.
+type Server struct {
+	cluster *Cluster
+}
+
+// NewServer wraps cluster for net/rpc registration.
+func NewServer(cluster *Cluster) *Server {
+	return &Server{cluster: cluster}
+}
+
+// Propose is the net/rpc entrypoint. If this node is not the leader, it
+// returns ErrNotLeader's text in reply.Err and populates reply.Leader; it
+// does not forward the call to the leader itself.
+func (s *Server) Propose(args *ProposeArgs, reply *ProposeReply) error {
+	cmd, err := commands.FromKind(args.Kind, args.CommandID, args.SlotIndex)
+	if err != nil {
+		reply.Err = err.Error()
+		return nil
+	}
+
+	result, err := s.cluster.Propose(cmd, 10*time.Second)
+	reply.Result = result
+	if err != nil {
+		reply.Err = err.Error()
+		if errors.Is(err, ErrNotLeader) {
+			reply.Leader = string(s.cluster.Leader())
+		}
+	}
+	return nil
+}
+
+// Serve registers srv and accepts net/rpc connections on addr until the
+// listener is closed.
+func Serve(srv *Server, addr string) (net.Listener, error) {
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("Cluster", srv); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go rpcServer.Accept(ln)
+	return ln, nil
+}
+
+// Client issues commands to a single cluster node over net/rpc. It does not
+// forward or retry against the leader itself: if c.addr isn't the leader,
+// Propose returns an error built from the node's reported text (see
+// ProposeReply.Leader for what's available to a caller that wants to redial).
+type Client struct {
+	addr string
+}
+
+// Dial returns a Client targeting addr.
+func Dial(addr string) *Client {
+	return &Client{addr: addr}
+}
+
+// Propose sends cmd to the node at c.addr. It returns whatever error that
+// node reports as-is, without retrying elsewhere - including when the node
+// isn't the leader.
+func (c *Client) Propose(cmd commands.Command) (service.Result, error) {
+	conn, err := rpc.Dial("tcp", c.addr)
+	if err != nil {
+		return service.Result{}, err
+	}
+	defer conn.Close()
+
+	args := &ProposeArgs{Kind: cmd.Name(), CommandID: cmd.CommandID()}
+	switch typed := cmd.(type) {
+	case *commands.ClaimCraftedComponent:
+		args.SlotIndex = typed.SlotIndex
+	case commands.CancelCraft:
+		args.SlotIndex = typed.SlotIndex
+	}
+	var reply ProposeReply
+	if err := conn.Call("Cluster.Propose", args, &reply); err != nil {
+		return service.Result{}, err
+	}
+	if reply.Err != "" {
+		return reply.Result, errors.New(reply.Err)
+	}
+	return reply.Result, nil
+}
@@ -1,11 +1,37 @@
 package commands
 
+import "fmt"
+
 // Command represents a typed command for the GameService executor.
 type Command interface {
 	CommandID() string
 	Name() string
 }
 
+// FromKind reconstructs a zero-value Command of the named kind so it can be
+// routed through GameService's command dispatch. It is shared by every
+// caller that only has a command's name, CommandID, and (where applicable)
+// SlotIndex to work with - e.g. a Raft log entry, a net/rpc request, or an
+// HTTP request - rather than the original typed command value. Output-only
+// fields (e.g. Settle.MintedScrap) are not restored since apply recomputes
+// them.
+func FromKind(kind, commandID string, slotIndex int) (Command, error) {
+	switch kind {
+	case "Settle":
+		return &Settle{CommandIDValue: commandID}, nil
+	case "UnlockComponentCrafting":
+		return UnlockComponentCrafting{CommandIDValue: commandID}, nil
+	case "CraftComponent":
+		return CraftComponent{CommandIDValue: commandID}, nil
+	case "ClaimCraftedComponent":
+		return &ClaimCraftedComponent{CommandIDValue: commandID, SlotIndex: slotIndex}, nil
+	case "CancelCraft":
+		return CancelCraft{CommandIDValue: commandID, SlotIndex: slotIndex}, nil
+	default:
+		return nil, fmt.Errorf("commands: unknown command kind %q", kind)
+	}
+}
+
 // SyncState requests a state snapshot without changing game state.
 type SyncState struct {
 	CommandIDValue string
@@ -63,6 +89,11 @@ func (c CraftComponent) Name() string {
 type ClaimCraftedComponent struct {
 	CommandIDValue   string
 	ComponentsGained uint64
+
+	// SlotIndex selects which ActiveCrafts entry to claim. A negative value
+	// (the convenience GameService.ClaimCraftedComponent's default) means
+	// "the first finished slot".
+	SlotIndex int
 }
 
 func (c *ClaimCraftedComponent) CommandID() string {
@@ -76,6 +107,10 @@ func (c *ClaimCraftedComponent) Name() string {
 // CancelCraft cancels a craft job and refunds scrap.
 type CancelCraft struct {
 	CommandIDValue string
+
+	// SlotIndex selects which ActiveCrafts entry to cancel. A negative value
+	// (the convenience GameService.CancelCraft's default) means "any slot".
+	SlotIndex int
 }
 
 func (c CancelCraft) CommandID() string {
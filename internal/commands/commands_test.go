@@ -61,3 +61,27 @@ func TestCancelCraftCommand(t *testing.T) {
 		t.Fatalf("expected name CancelCraft got %s", cmd.Name())
 	}
 }
+
+func TestFromKindPreservesSlotIndex(t *testing.T) {
+	claim, err := FromKind("ClaimCraftedComponent", "claim-1", 2)
+	if err != nil {
+		t.Fatalf("ClaimCraftedComponent: %v", err)
+	}
+	if got := claim.(*ClaimCraftedComponent).SlotIndex; got != 2 {
+		t.Fatalf("expected SlotIndex 2 got %d", got)
+	}
+
+	cancel, err := FromKind("CancelCraft", "cancel-1", -1)
+	if err != nil {
+		t.Fatalf("CancelCraft: %v", err)
+	}
+	if got := cancel.(CancelCraft).SlotIndex; got != -1 {
+		t.Fatalf("expected SlotIndex -1 got %d", got)
+	}
+}
+
+func TestFromKindUnknownKind(t *testing.T) {
+	if _, err := FromKind("NotACommand", "id-1", -1); err == nil {
+		t.Fatalf("expected an error for an unknown kind")
+	}
+}
@@ -6,6 +6,20 @@ type Config struct {
 	CraftComponentTechnologyCost uint64
 	CraftComponentCost           uint64
 	CraftDurationSecs            uint64
+
+	// MaxParallelCrafts caps how many craft jobs can be active at once. A
+	// value of 1 preserves the original single-slot behavior, including
+	// ErrCraftInProgress when MaxPendingCrafts is also 0.
+	MaxParallelCrafts uint64
+
+	// MaxPendingCrafts caps how many craft requests can queue once every
+	// slot is busy, rather than being rejected outright. Zero disables
+	// queuing.
+	MaxPendingCrafts uint64
+
+	// SnapshotEveryNEvents controls how often a persistent GameService writes
+	// a snapshot and truncates its write-ahead log. Zero disables auto-snapshotting.
+	SnapshotEveryNEvents uint64
 }
 
 // Default returns the standard game configuration.
@@ -15,5 +29,8 @@ func Default() Config {
 		CraftComponentTechnologyCost: 10,
 		CraftComponentCost:           10,
 		CraftDurationSecs:            10,
+		MaxParallelCrafts:            1,
+		MaxPendingCrafts:             0,
+		SnapshotEveryNEvents:         100,
 	}
 }
@@ -17,4 +17,7 @@ func TestDefault(t *testing.T) {
 	if cfg.CraftDurationSecs != 10 {
 		t.Fatalf("CraftDurationSecs: expected 10 got %d", cfg.CraftDurationSecs)
 	}
+	if cfg.SnapshotEveryNEvents != 100 {
+		t.Fatalf("SnapshotEveryNEvents: expected 100 got %d", cfg.SnapshotEveryNEvents)
+	}
 }
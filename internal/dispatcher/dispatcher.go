@@ -0,0 +1,247 @@
+// Package dispatcher runs commands.Command values against a
+// service.GameService from a bounded queue and a pool of worker goroutines,
+// so the game can run as a service instead of requiring direct, synchronous
+// method calls (which remain available and unaffected).
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"scraps/internal/clock"
+	"scraps/internal/commands"
+	"scraps/internal/events"
+	"scraps/internal/service"
+)
+
+// ErrQueueFull is returned by Enqueue when the queue has no spare capacity.
+var ErrQueueFull = errors.New("dispatcher: queue full")
+
+// ErrClosed is returned by Enqueue after Shutdown has been called.
+var ErrClosed = errors.New("dispatcher: closed")
+
+// ErrUnknownCommand is returned by Await when a command ID's outcome was
+// recorded but reaped (see resultTTL) before Await could read it. Await does
+// not check whether a command ID was ever enqueued: for an ID with no
+// recorded outcome it blocks until one is recorded or its ctx is done.
+var ErrUnknownCommand = errors.New("dispatcher: unknown command id")
+
+type outcome struct {
+	result   service.Result
+	err      error
+	storedAt time.Time
+}
+
+// Dispatcher owns a bounded queue of commands.Command values and a pool of
+// worker goroutines that execute them against a service.GameService,
+// publishing the resulting events.Event to registered subscribers.
+type Dispatcher struct {
+	svc   *service.GameService
+	clock clock.Clock
+	queue chan commands.Command
+
+	resultTTL time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	results map[string]outcome
+	waiters map[string][]chan struct{}
+
+	subsMu      sync.Mutex
+	subscribers []chan events.Event
+
+	processed uint64
+	failed    uint64
+
+	shutdownCh chan struct{}
+	wg         sync.WaitGroup
+}
+
+// New starts a Dispatcher with the given number of workers, a queue bounded
+// to queueSize, and result entries evicted resultTTL after they're recorded.
+func New(svc *service.GameService, clk clock.Clock, workers, queueSize int, resultTTL time.Duration) *Dispatcher {
+	d := &Dispatcher{
+		svc:        svc,
+		clock:      clk,
+		queue:      make(chan commands.Command, queueSize),
+		resultTTL:  resultTTL,
+		results:    make(map[string]outcome),
+		waiters:    make(map[string][]chan struct{}),
+		shutdownCh: make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	d.wg.Add(1)
+	go d.reapLoop()
+
+	return d
+}
+
+// Enqueue queues cmd for a worker to execute, returning ErrQueueFull if the
+// queue has no spare capacity and ErrClosed once Shutdown has been called.
+func (d *Dispatcher) Enqueue(cmd commands.Command) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+
+	select {
+	case d.queue <- cmd:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Await blocks until commandID has a recorded outcome or ctx is done,
+// returning the same Result and error Enqueue's worker produced.
+func (d *Dispatcher) Await(ctx context.Context, commandID string) (service.Result, error) {
+	d.mu.Lock()
+	if out, ok := d.results[commandID]; ok {
+		d.mu.Unlock()
+		return out.result, out.err
+	}
+	ready := make(chan struct{})
+	d.waiters[commandID] = append(d.waiters[commandID], ready)
+	d.mu.Unlock()
+
+	select {
+	case <-ready:
+		d.mu.RLock()
+		out, ok := d.results[commandID]
+		d.mu.RUnlock()
+		if !ok {
+			return service.Result{}, ErrUnknownCommand
+		}
+		return out.result, out.err
+	case <-ctx.Done():
+		return service.Result{}, ctx.Err()
+	}
+}
+
+// Processed returns the number of commands a worker has finished executing.
+func (d *Dispatcher) Processed() uint64 { return atomic.LoadUint64(&d.processed) }
+
+// Failed returns the number of processed commands whose execution returned
+// a non-nil error.
+func (d *Dispatcher) Failed() uint64 { return atomic.LoadUint64(&d.failed) }
+
+// QueueDepth returns the number of commands currently buffered in the queue.
+func (d *Dispatcher) QueueDepth() int { return len(d.queue) }
+
+// Subscribe returns a channel of events produced by commands this dispatcher
+// executes, and a cancel func to stop receiving and release the channel.
+func (d *Dispatcher) Subscribe(buf int) (<-chan events.Event, func()) {
+	ch := make(chan events.Event, buf)
+
+	d.subsMu.Lock()
+	d.subscribers = append(d.subscribers, ch)
+	d.subsMu.Unlock()
+
+	cancel := func() {
+		d.subsMu.Lock()
+		defer d.subsMu.Unlock()
+		for i, sub := range d.subscribers {
+			if sub == ch {
+				d.subscribers = append(d.subscribers[:i], d.subscribers[i+1:]...)
+				break
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// Shutdown stops accepting new commands, lets workers drain whatever is
+// already queued, and returns once every worker has exited.
+func (d *Dispatcher) Shutdown() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	d.mu.Unlock()
+
+	close(d.shutdownCh)
+	close(d.queue)
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+
+	for cmd := range d.queue {
+		result, err := d.svc.Execute(cmd)
+		d.recordOutcome(cmd.CommandID(), result, err)
+
+		atomic.AddUint64(&d.processed, 1)
+		if err != nil {
+			atomic.AddUint64(&d.failed, 1)
+		}
+		d.publish(result.Events)
+	}
+}
+
+func (d *Dispatcher) recordOutcome(commandID string, result service.Result, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.results[commandID] = outcome{result: result, err: err, storedAt: d.clock.Now()}
+
+	for _, ready := range d.waiters[commandID] {
+		close(ready)
+	}
+	delete(d.waiters, commandID)
+}
+
+func (d *Dispatcher) publish(evs []events.Event) {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+
+	for _, sub := range d.subscribers {
+		for _, ev := range evs {
+			select {
+			case sub <- ev:
+			default:
+				// Slow subscriber: drop rather than block the worker pool.
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) reapLoop() {
+	defer d.wg.Done()
+
+	timer := d.clock.NewTimer(d.resultTTL)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-d.shutdownCh:
+			return
+		case <-timer.C():
+			d.reapExpired()
+			timer = d.clock.NewTimer(d.resultTTL)
+		}
+	}
+}
+
+func (d *Dispatcher) reapExpired() {
+	cutoff := d.clock.Now().Add(-d.resultTTL)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, out := range d.results {
+		if out.storedAt.Before(cutoff) {
+			delete(d.results, id)
+		}
+	}
+}
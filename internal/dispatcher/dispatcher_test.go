@@ -0,0 +1,185 @@
+package dispatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"scraps/internal/clock"
+	"scraps/internal/commands"
+	"scraps/internal/config"
+	"scraps/internal/service"
+)
+
+func TestEnqueueAndAwaitSettle(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := service.NewGameService(config.Default(), clk, start)
+	d := New(svc, clk, 2, 8, time.Minute)
+	defer d.Shutdown()
+
+	clk.Advance(3 * time.Second)
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	result, err := d.Await(context.Background(), "settle-1")
+	if err != nil {
+		t.Fatalf("await: %v", err)
+	}
+	if result.State.Scrap != 3 {
+		t.Fatalf("expected scrap 3 got %d", result.State.Scrap)
+	}
+	if d.Processed() != 1 {
+		t.Fatalf("expected 1 processed got %d", d.Processed())
+	}
+	if d.Failed() != 0 {
+		t.Fatalf("expected 0 failed got %d", d.Failed())
+	}
+}
+
+func TestAwaitReportsCommandError(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := service.NewGameService(config.Default(), clk, start)
+	d := New(svc, clk, 1, 4, time.Minute)
+	defer d.Shutdown()
+
+	if err := d.Enqueue(commands.CraftComponent{CommandIDValue: "craft-1"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	if _, err := d.Await(context.Background(), "craft-1"); err != service.ErrCraftingLocked {
+		t.Fatalf("expected ErrCraftingLocked got %v", err)
+	}
+	if d.Failed() != 1 {
+		t.Fatalf("expected 1 failed got %d", d.Failed())
+	}
+}
+
+func TestEnqueueReturnsErrQueueFullWhenSaturated(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := service.NewGameService(config.Default(), clk, start)
+	// No workers: nothing drains the queue, so it saturates deterministically.
+	d := New(svc, clk, 0, 1, time.Minute)
+	defer d.Shutdown()
+
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("expected first enqueue to succeed got %v", err)
+	}
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-2"}); err != ErrQueueFull {
+		t.Fatalf("expected ErrQueueFull got %v", err)
+	}
+}
+
+func TestEnqueueAfterShutdownReturnsErrClosed(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := service.NewGameService(config.Default(), clk, start)
+	d := New(svc, clk, 1, 4, time.Minute)
+
+	d.Shutdown()
+
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-1"}); err != ErrClosed {
+		t.Fatalf("expected ErrClosed got %v", err)
+	}
+}
+
+func TestAwaitUnblocksOnContextCancel(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := service.NewGameService(config.Default(), clk, start)
+	// No workers: the command is never processed, so Await only returns via ctx.
+	d := New(svc, clk, 0, 4, time.Minute)
+	defer d.Shutdown()
+
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := d.Await(ctx, "settle-1"); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded got %v", err)
+	}
+}
+
+func TestSubscribeReceivesPublishedEvents(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := service.NewGameService(config.Default(), clk, start)
+	d := New(svc, clk, 1, 4, time.Minute)
+	defer d.Shutdown()
+
+	sub, cancel := d.Subscribe(4)
+	defer cancel()
+
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := d.Await(context.Background(), "settle-1"); err != nil {
+		t.Fatalf("await: %v", err)
+	}
+
+	select {
+	case ev := <-sub:
+		if ev.CommandID != "settle-1" {
+			t.Fatalf("expected event for settle-1 got %s", ev.CommandID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a published event")
+	}
+}
+
+func TestQueueDepthReflectsBufferedCommands(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := service.NewGameService(config.Default(), clk, start)
+	d := New(svc, clk, 0, 4, time.Minute)
+	defer d.Shutdown()
+
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-2"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	if depth := d.QueueDepth(); depth != 2 {
+		t.Fatalf("expected queue depth 2 got %d", depth)
+	}
+}
+
+func TestResultsAreReapedAfterTTL(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := service.NewGameService(config.Default(), clk, start)
+	d := New(svc, clk, 1, 4, time.Minute)
+	defer d.Shutdown()
+
+	if err := d.Enqueue(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := d.Await(context.Background(), "settle-1"); err != nil {
+		t.Fatalf("await: %v", err)
+	}
+
+	clk.BlockUntil(1)
+	clk.Advance(2 * time.Minute)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		d.mu.RLock()
+		_, ok := d.results["settle-1"]
+		d.mu.RUnlock()
+		if !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected settle-1's result to be reaped after TTL")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
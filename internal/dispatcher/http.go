@@ -0,0 +1,81 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"scraps/internal/commands"
+)
+
+// commandRequest is the JSON body accepted by Handler: Kind names one of the
+// commands package's command types and CommandID is the caller-chosen ID
+// used to Await the result.
+type commandRequest struct {
+	Kind      string `json:"kind"`
+	CommandID string `json:"command_id"`
+
+	// SlotIndex carries ClaimCraftedComponent/CancelCraft's SlotIndex field,
+	// since commands.FromKind otherwise has no way to tell a reconstructed
+	// command which craft slot the original targeted. A negative value means
+	// "auto-pick"; Handler presets -1, so a request that omits this field
+	// auto-picks too.
+	SlotIndex int `json:"slot_index"`
+}
+
+// Handler translates POSTed commandRequest JSON into a commands.Command,
+// enqueues it on d, and responds once the command has been processed (or the
+// request context is canceled first).
+func Handler(d *Dispatcher) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		req := commandRequest{SlotIndex: -1}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("decode request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		cmd, err := commands.FromKind(req.Kind, req.CommandID, req.SlotIndex)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := d.Enqueue(cmd); err != nil {
+			status := http.StatusInternalServerError
+			if err == ErrQueueFull {
+				status = http.StatusTooManyRequests
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		result, err := d.Await(r.Context(), req.CommandID)
+		if err != nil && err != context.Canceled && err != context.DeadlineExceeded {
+			// The command itself failed (e.g. ErrInsufficientScrap); still
+			// report the resulting state alongside the error message.
+			writeJSON(w, http.StatusOK, map[string]any{
+				"state": result.State,
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusGatewayTimeout)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"state": result.State})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -8,7 +8,16 @@ type State struct {
 	Components       uint64
 	CraftingUnlocked bool
 	LastSettledAt    time.Time
-	ActiveCraft      *CraftJob
+
+	// ActiveCrafts holds up to config.MaxParallelCrafts in-progress craft
+	// jobs, indexed by the slot a ClaimCraftedComponent/CancelCraft command
+	// targets via its SlotIndex field.
+	ActiveCrafts []CraftJob
+
+	// PendingCrafts counts craft requests queued because every slot was busy
+	// when they were made (their scrap cost was already deducted at request
+	// time). One is promoted into ActiveCrafts whenever a slot frees up.
+	PendingCrafts uint64
 }
 
 // CraftJob represents an in-progress component craft.
@@ -17,11 +26,3 @@ type CraftJob struct {
 	FinishesAt time.Time
 	ScrapCost  uint64
 }
-
-func cloneCraftJob(job *CraftJob) *CraftJob {
-	if job == nil {
-		return nil
-	}
-	clone := *job
-	return &clone
-}
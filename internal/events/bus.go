@@ -0,0 +1,116 @@
+package events
+
+import "sync"
+
+// CancelFunc stops a subscription and releases its channel.
+type CancelFunc func()
+
+// EventBus fans a stream of Events out to subscribers and keeps a bounded
+// ring buffer so a subscriber that connects late can Replay everything since
+// a given event ID instead of only seeing events published after it joined.
+type EventBus struct {
+	mu          sync.Mutex
+	ring        []Event
+	ringSize    int
+	subscribers map[<-chan Event]*subscriber
+}
+
+type subscriber struct {
+	ch      chan Event
+	filter  EventType
+	dropped uint64
+}
+
+// NewEventBus creates an EventBus whose Replay buffer holds at most ringSize
+// of the most recently published events.
+func NewEventBus(ringSize int) *EventBus {
+	return &EventBus{
+		ringSize:    ringSize,
+		subscribers: make(map[<-chan Event]*subscriber),
+	}
+}
+
+// Subscribe registers a new subscriber and returns a channel that receives
+// every published Event matching filter ("" matches all types), along with a
+// cancel func that unregisters it. If a subscriber's channel is full when an
+// event is published, the oldest buffered event is dropped to make room and
+// the subscriber's Dropped count is incremented; Dropped is queryable via the
+// bus using the same channel this method returns.
+func (b *EventBus) Subscribe(filter EventType, buf int) (<-chan Event, CancelFunc) {
+	sub := &subscriber{ch: make(chan Event, buf), filter: filter}
+
+	b.mu.Lock()
+	b.subscribers[sub.ch] = sub
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		delete(b.subscribers, sub.ch)
+		b.mu.Unlock()
+	}
+	return sub.ch, cancel
+}
+
+// Dropped returns how many events have been dropped for the subscriber
+// identified by ch, the channel Subscribe returned. It returns 0 for a
+// channel that was never subscribed or has since been canceled.
+func (b *EventBus) Dropped(ch <-chan Event) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, ok := b.subscribers[ch]
+	if !ok {
+		return 0
+	}
+	return sub.dropped
+}
+
+// Publish appends ev to the ring buffer and delivers it to every subscriber
+// whose filter matches. Publish never blocks: a subscriber that can't keep up
+// loses its oldest buffered event rather than stalling the publisher.
+func (b *EventBus) Publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.ring = append(b.ring, ev)
+	if b.ringSize > 0 && len(b.ring) > b.ringSize {
+		b.ring = b.ring[len(b.ring)-b.ringSize:]
+	}
+
+	for _, sub := range b.subscribers {
+		if sub.filter != "" && sub.filter != ev.Type {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+			continue
+		default:
+		}
+
+		select {
+		case <-sub.ch:
+		default:
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+		}
+		sub.dropped++
+	}
+}
+
+// Replay returns the buffered events with ID greater than sinceID, oldest
+// first. It may return fewer events than were actually published if the ring
+// buffer has since evicted them.
+func (b *EventBus) Replay(sinceID int64) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []Event
+	for _, ev := range b.ring {
+		if ev.ID > sinceID {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventBusPublishDeliversToMatchingSubscriber(t *testing.T) {
+	bus := NewEventBus(4)
+	sub, cancel := bus.Subscribe(EventTypeScrapSettled, 4)
+	defer cancel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bus.Publish(New(1, now, "cmd-1", EventTypeScrapSettled, nil))
+	bus.Publish(New(2, now, "cmd-2", EventTypeCraftingUnlocked, nil))
+
+	select {
+	case ev := <-sub:
+		if ev.CommandID != "cmd-1" {
+			t.Fatalf("expected cmd-1 got %s", ev.CommandID)
+		}
+	default:
+		t.Fatalf("expected a matching event to be delivered")
+	}
+
+	select {
+	case ev := <-sub:
+		t.Fatalf("expected no second event, got %+v", ev)
+	default:
+	}
+}
+
+func TestEventBusSubscribeWithEmptyFilterReceivesEverything(t *testing.T) {
+	bus := NewEventBus(4)
+	sub, cancel := bus.Subscribe("", 4)
+	defer cancel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bus.Publish(New(1, now, "cmd-1", EventTypeScrapSettled, nil))
+	bus.Publish(New(2, now, "cmd-2", EventTypeCraftingUnlocked, nil))
+
+	for _, wantID := range []int64{1, 2} {
+		select {
+		case ev := <-sub:
+			if ev.ID != wantID {
+				t.Fatalf("expected ID %d got %d", wantID, ev.ID)
+			}
+		default:
+			t.Fatalf("expected event %d", wantID)
+		}
+	}
+}
+
+func TestEventBusDropsOldestWhenSubscriberChannelIsFull(t *testing.T) {
+	bus := NewEventBus(8)
+	sub, cancel := bus.Subscribe("", 1)
+	defer cancel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bus.Publish(New(1, now, "cmd-1", EventTypeScrapSettled, nil))
+	bus.Publish(New(2, now, "cmd-2", EventTypeScrapSettled, nil))
+
+	ev := <-sub
+	if ev.ID != 2 {
+		t.Fatalf("expected the oldest event to be dropped, leaving ID 2, got %d", ev.ID)
+	}
+	if got := bus.Dropped(sub); got != 1 {
+		t.Fatalf("expected Dropped 1 got %d", got)
+	}
+}
+
+func TestEventBusReplayReturnsEventsSinceID(t *testing.T) {
+	bus := NewEventBus(8)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := int64(1); i <= 3; i++ {
+		bus.Publish(New(i, now, "cmd", EventTypeScrapSettled, nil))
+	}
+
+	replayed := bus.Replay(1)
+	if len(replayed) != 2 || replayed[0].ID != 2 || replayed[1].ID != 3 {
+		t.Fatalf("expected events 2 and 3, got %+v", replayed)
+	}
+}
+
+func TestEventBusReplayEvictsBeyondRingSize(t *testing.T) {
+	bus := NewEventBus(2)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := int64(1); i <= 3; i++ {
+		bus.Publish(New(i, now, "cmd", EventTypeScrapSettled, nil))
+	}
+
+	replayed := bus.Replay(0)
+	if len(replayed) != 2 || replayed[0].ID != 2 || replayed[1].ID != 3 {
+		t.Fatalf("expected only the last 2 ring entries, got %+v", replayed)
+	}
+}
+
+func TestEventBusCancelStopsDelivery(t *testing.T) {
+	bus := NewEventBus(4)
+	sub, cancel := bus.Subscribe("", 4)
+	cancel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bus.Publish(New(1, now, "cmd-1", EventTypeScrapSettled, nil))
+
+	select {
+	case ev, ok := <-sub:
+		if ok {
+			t.Fatalf("expected no delivery after cancel, got %+v", ev)
+		}
+	default:
+	}
+
+	if got := bus.Dropped(sub); got != 0 {
+		t.Fatalf("expected Dropped 0 for a canceled subscriber got %d", got)
+	}
+}
@@ -5,7 +5,13 @@ import "time"
 // EventType describes the kind of event emitted by the game.
 type EventType string
 
-const EventTypeScrapSettled EventType = "ScrapSettled"
+const (
+	EventTypeScrapSettled           EventType = "ScrapSettled"
+	EventTypeCraftingUnlocked       EventType = "CraftingUnlocked"
+	EventTypeComponentCraftStarted  EventType = "ComponentCraftStarted"
+	EventTypeComponentCraftClaimed  EventType = "ComponentCraftClaimed"
+	EventTypeComponentCraftCanceled EventType = "ComponentCraftCanceled"
+)
 
 // ScrapSettledData is the payload for a scrap settlement event.
 type ScrapSettledData struct {
@@ -14,9 +20,31 @@ type ScrapSettledData struct {
 	To     time.Time
 }
 
+// CraftingUnlockedData is the payload for a component-crafting-unlocked event.
+type CraftingUnlockedData struct {
+	Cost uint64
+}
+
+// ComponentCraftStartedData is the payload for a craft-job-started event.
+type ComponentCraftStartedData struct {
+	ScrapCost  uint64
+	StartedAt  time.Time
+	FinishesAt time.Time
+}
+
+// ComponentCraftClaimedData is the payload for a craft-job-claimed event.
+type ComponentCraftClaimedData struct {
+	ComponentsGained uint64
+}
+
+// ComponentCraftCanceledData is the payload for a craft-job-canceled event.
+type ComponentCraftCanceledData struct {
+	RefundedScrap uint64
+}
+
 // Event represents a game event produced by command execution.
 type Event struct {
-	ID        uint64
+	ID        int64
 	At        time.Time
 	CommandID string
 	Type      EventType
@@ -24,7 +52,7 @@ type Event struct {
 }
 
 // New constructs a new Event with the provided fields.
-func New(id uint64, at time.Time, commandID string, eventType EventType, data any) Event {
+func New(id int64, at time.Time, commandID string, eventType EventType, data any) Event {
 	return Event{
 		ID:        id,
 		At:        at,
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"scraps/internal/clock"
+	"scraps/internal/commands"
+	"scraps/internal/config"
+	"scraps/internal/domain"
+	"scraps/internal/events"
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.gob"
+)
+
+// walRecord is the durable, replayable representation of one applied
+// command, or, when Scheduled is true, of a command queued via ScheduleAt (or
+// the CraftComponent auto-claim hook) to run at a future time rather than one
+// already applied. It otherwise omits command-specific payload fields: replay
+// reconstructs a near-zero-value command of the same type and reapplies it
+// through the same locked methods Execute uses, with the clock pinned to At.
+type walRecord struct {
+	EventID     int64
+	CommandName string
+	CommandID   string
+	At          time.Time
+	Scheduled   bool
+
+	// SlotIndex carries ClaimCraftedComponent/CancelCraft's SlotIndex field,
+	// since commandFromRecord otherwise has no way to tell a replayed command
+	// which craft slot the original targeted.
+	SlotIndex int
+}
+
+// snapshotFile is the on-disk representation written by persistence.snapshot.
+type snapshotFile struct {
+	State         domain.State
+	EventSequence int64
+}
+
+// fixedClock satisfies clock.Clock by always returning a single instant. It
+// is used both to pin Execute's live apply to the instant written to the
+// WAL, and to replay WAL entries deterministically. Only Now is ever called
+// on it: applyLocked's command handlers read the clock but never wait on
+// it, so the waiting methods are implemented to satisfy the interface and
+// panic if that assumption ever stops holding.
+type fixedClock time.Time
+
+func (f fixedClock) Now() time.Time { return time.Time(f) }
+
+func (f fixedClock) After(d time.Duration) <-chan time.Time {
+	panic("service: fixedClock.After called; a pinned replay/apply clock never waits")
+}
+
+func (f fixedClock) NewTimer(d time.Duration) clock.Timer {
+	panic("service: fixedClock.NewTimer called; a pinned replay/apply clock never waits")
+}
+
+func (f fixedClock) Sleep(d time.Duration) {
+	panic("service: fixedClock.Sleep called; a pinned replay/apply clock never waits")
+}
+
+// persistence owns the on-disk write-ahead log and snapshot for a
+// GameService. A nil *persistence means the service is purely in-memory.
+type persistence struct {
+	dir                  string
+	walFile              *os.File
+	walEnc               *gob.Encoder
+	snapshotEveryNEvents uint64
+	appliedSinceSnapshot uint64
+}
+
+// OpenGameService opens or creates a durable game service rooted at dir. It
+// loads the newest snapshot (if any), replays WAL entries recorded after the
+// snapshot's event sequence to reconstruct state deterministically, then
+// leaves the WAL open for subsequent Execute calls to append to.
+func OpenGameService(cfg config.Config, clk clock.Clock, startTime time.Time, dir string) (*GameService, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("persistence: create dir %s: %w", dir, err)
+	}
+
+	svc := NewGameService(cfg, clk, startTime)
+	p := &persistence{dir: dir, snapshotEveryNEvents: cfg.SnapshotEveryNEvents}
+
+	if err := p.loadSnapshot(&svc.state, &svc.eventSequence); err != nil {
+		return nil, fmt.Errorf("persistence: load snapshot: %w", err)
+	}
+	if err := p.replayWAL(svc); err != nil {
+		return nil, fmt.Errorf("persistence: replay wal: %w", err)
+	}
+	svc.clock = clk // replayWAL pins the clock per-record; restore the live clock
+	if err := p.openWAL(); err != nil {
+		return nil, fmt.Errorf("persistence: open wal: %w", err)
+	}
+
+	svc.persistence = p
+	return svc, nil
+}
+
+func (p *persistence) walPath() string      { return filepath.Join(p.dir, walFileName) }
+func (p *persistence) snapshotPath() string { return filepath.Join(p.dir, snapshotFileName) }
+
+func (p *persistence) loadSnapshot(state *domain.State, eventSequence *int64) error {
+	f, err := os.Open(p.snapshotPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var snap snapshotFile
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		return err
+	}
+	*state = snap.State
+	*eventSequence = snap.EventSequence
+	return nil
+}
+
+// replayWAL holds svc.mu for its entire run, even though svc isn't reachable
+// by any other caller yet at OpenGameService time: svc.runScheduler is
+// already started and a Scheduled record whose time has already passed would
+// otherwise let it call Execute concurrently with replay's direct,
+// lock-free mutation of svc.state below. Taking the lock here makes that
+// Execute simply block until replay finishes: runApplier receives its
+// proposal but waits on svc.mu before applying it.
+func (p *persistence) replayWAL(svc *GameService) error {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	f, err := os.Open(p.walPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	for {
+		var rec walRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if rec.Scheduled {
+			// Not yet applied when the service last closed: hand it back to
+			// the scheduler rather than replaying it as state. If it was
+			// actually applied for real before the crash, that apply has its
+			// own (non-Scheduled) record later in the WAL and will mutate
+			// state normally; this stale re-queue then just no-ops when it
+			// fires, since resolveCraftSlotLocked already treats "nothing to
+			// claim" as a harmless failure.
+			svc.sched.add(scheduledJob{At: rec.At, Cmd: commandFromRecord(rec)})
+			continue
+		}
+		if rec.EventID <= svc.eventSequence {
+			continue
+		}
+
+		cmd := commandFromRecord(rec)
+		svc.clock = fixedClock(rec.At)
+		evType, data, err := svc.applyLocked(cmd) // replay faithfully reapplies an already-validated command
+
+		svc.eventSequence = rec.EventID
+		eventItem := events.Event{
+			ID:        rec.EventID,
+			At:        rec.At,
+			CommandID: rec.CommandID,
+			Type:      evType,
+			Data:      data,
+		}
+		svc.bus.Publish(eventItem)
+
+		// Mirror applyAtLocked's idempotent-cache write so a client that
+		// retries rec.CommandID after a restart gets this replayed outcome
+		// instead of rec.CommandID running again from scratch.
+		svc.idempotent[rec.CommandID] = Result{
+			State:  svc.snapshotLocked(),
+			Events: []events.Event{eventItem},
+			Err:    err,
+		}
+	}
+	return nil
+}
+
+// commandFromRecord reconstructs a zero-value command of the type named by
+// rec so it can be routed through GameService.applyLocked's type switch.
+// Output-only fields (e.g. Settle.MintedScrap) are not restored since
+// applyLocked recomputes them deterministically.
+func commandFromRecord(rec walRecord) commands.Command {
+	switch rec.CommandName {
+	case "Settle":
+		return &commands.Settle{CommandIDValue: rec.CommandID}
+	case "UnlockComponentCrafting":
+		return commands.UnlockComponentCrafting{CommandIDValue: rec.CommandID}
+	case "CraftComponent":
+		return commands.CraftComponent{CommandIDValue: rec.CommandID}
+	case "ClaimCraftedComponent":
+		return &commands.ClaimCraftedComponent{CommandIDValue: rec.CommandID, SlotIndex: rec.SlotIndex}
+	case "CancelCraft":
+		return commands.CancelCraft{CommandIDValue: rec.CommandID, SlotIndex: rec.SlotIndex}
+	default:
+		return commands.SyncState{CommandIDValue: rec.CommandID}
+	}
+}
+
+func (p *persistence) openWAL() error {
+	f, err := os.OpenFile(p.walPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	p.walFile = f
+	p.walEnc = gob.NewEncoder(f)
+	return nil
+}
+
+// append durably writes rec to the WAL before the caller mutates state.
+func (p *persistence) append(rec walRecord) error {
+	if err := p.walEnc.Encode(rec); err != nil {
+		return err
+	}
+	return p.walFile.Sync()
+}
+
+// snapshot atomically writes state and truncates the WAL to empty, since
+// every entry in it is now superseded by the snapshot.
+func (p *persistence) snapshot(state domain.State, eventSequence int64) error {
+	tmp, err := os.CreateTemp(p.dir, "snapshot-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if err := gob.NewEncoder(tmp).Encode(snapshotFile{State: state, EventSequence: eventSequence}); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, p.snapshotPath()); err != nil {
+		return err
+	}
+
+	if err := p.walFile.Close(); err != nil {
+		return err
+	}
+	f, err := os.Create(p.walPath())
+	if err != nil {
+		return err
+	}
+	p.walFile = f
+	p.walEnc = gob.NewEncoder(f)
+	p.appliedSinceSnapshot = 0
+	return nil
+}
+
+func (p *persistence) close() error {
+	if p.walFile == nil {
+		return nil
+	}
+	return p.walFile.Close()
+}
+
+// maybeAutoSnapshotLocked snapshots when SnapshotEveryNEvents have been
+// applied since the last one. Callers must hold s.mu.
+func (s *GameService) maybeAutoSnapshotLocked() error {
+	s.persistence.appliedSinceSnapshot++
+	if s.persistence.snapshotEveryNEvents == 0 || s.persistence.appliedSinceSnapshot < s.persistence.snapshotEveryNEvents {
+		return nil
+	}
+	return s.persistence.snapshot(s.state, s.eventSequence)
+}
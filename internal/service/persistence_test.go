@@ -0,0 +1,226 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"scraps/internal/clock"
+	"scraps/internal/commands"
+	"scraps/internal/config"
+)
+
+func TestOpenGameServiceEmptyDir(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+
+	svc, err := OpenGameService(config.Default(), clk, start, dir)
+	if err != nil {
+		t.Fatalf("expected success got %v", err)
+	}
+	defer svc.Close()
+
+	got := svc.GetState()
+	if got.Scrap != 0 || !got.LastSettledAt.Equal(start) {
+		t.Fatalf("unexpected initial state %+v", got)
+	}
+}
+
+func TestPersistentGameServiceRestartReplaysWAL(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+
+	cfg := config.Default()
+	cfg.CraftComponentTechnologyCost = 5
+	cfg.SnapshotEveryNEvents = 0 // disable auto-snapshot so the WAL carries everything
+
+	svc, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	clk.Advance(5 * time.Second)
+	if mint := svc.Settle(); mint != 5 {
+		t.Fatalf("expected mint 5 got %d", mint)
+	}
+	if err := svc.UnlockComponentCrafting(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	got := reopened.GetState()
+	if got.Scrap != 0 {
+		t.Fatalf("expected scrap 0 (spent on unlock) got %d", got.Scrap)
+	}
+	if !got.CraftingUnlocked {
+		t.Fatalf("expected CraftingUnlocked true after replay")
+	}
+}
+
+// TestPersistentGameServiceReplayPopulatesIdempotentCache restarts a service
+// after an UnlockComponentCrafting command was applied and durably logged,
+// then retries that same CommandID. Without the idempotent cache populated
+// from the replayed WAL, the retry would re-run unlockComponentCraftingLocked
+// from scratch and get ErrAlreadyUnlocked instead of the original (successful)
+// outcome - exactly the double-apply-on-retry bug the idempotent cache exists
+// to prevent.
+func TestPersistentGameServiceReplayPopulatesIdempotentCache(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+
+	cfg := config.Default()
+	cfg.CraftComponentTechnologyCost = 5
+	cfg.SnapshotEveryNEvents = 0 // disable auto-snapshot so the WAL carries everything
+
+	svc, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	clk.Advance(5 * time.Second)
+	if _, err := svc.Execute(commands.UnlockComponentCrafting{CommandIDValue: "unlock-1"}); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	if _, err := reopened.Execute(commands.UnlockComponentCrafting{CommandIDValue: "unlock-1"}); err != nil {
+		t.Fatalf("expected retried unlock-1 to replay its original success, got %v", err)
+	}
+}
+
+func TestPersistentGameServiceSnapshotTruncatesWAL(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+
+	cfg := config.Default()
+	cfg.SnapshotEveryNEvents = 0
+
+	svc, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	clk.Advance(3 * time.Second)
+	svc.Settle()
+	if err := svc.Snapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, walFileName)); err != nil {
+		t.Fatalf("stat wal: %v", err)
+	} else if info.Size() != 0 {
+		t.Fatalf("expected an empty WAL after Snapshot got %d bytes", info.Size())
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	got := reopened.GetState()
+	if got.Scrap != 3 {
+		t.Fatalf("expected scrap 3 from snapshot got %d", got.Scrap)
+	}
+}
+
+func TestPersistentGameServiceAutoSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+
+	cfg := config.Default()
+	cfg.SnapshotEveryNEvents = 2
+
+	svc, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer svc.Close()
+
+	clk.Advance(1 * time.Second)
+	svc.Settle()
+	clk.Advance(1 * time.Second)
+	svc.Settle()
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err != nil {
+		t.Fatalf("expected snapshot file after %d events: %v", cfg.SnapshotEveryNEvents, err)
+	}
+}
+
+func TestCompactTruncatesWAL(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+
+	cfg := config.Default()
+	cfg.SnapshotEveryNEvents = 0
+
+	svc, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	clk.Advance(4 * time.Second)
+	svc.Settle()
+	if err := svc.Compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, walFileName)); err != nil {
+		t.Fatalf("stat wal: %v", err)
+	} else if info.Size() != 0 {
+		t.Fatalf("expected an empty WAL after Compact got %d bytes", info.Size())
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	got := reopened.GetState()
+	if got.Scrap != 4 {
+		t.Fatalf("expected scrap 4 from compacted snapshot got %d", got.Scrap)
+	}
+}
+
+func TestNewGameServiceHasNoPersistence(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc := NewGameService(config.Default(), clock.RealClock{}, start)
+
+	if err := svc.Snapshot(); err != ErrPersistenceDisabled {
+		t.Fatalf("expected ErrPersistenceDisabled got %v", err)
+	}
+	if err := svc.Compact(); err != ErrPersistenceDisabled {
+		t.Fatalf("expected ErrPersistenceDisabled got %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("expected Close to be a no-op got %v", err)
+	}
+}
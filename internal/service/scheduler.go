@@ -0,0 +1,204 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"scraps/internal/commands"
+)
+
+// rescheduleDelay is how far out a due-but-not-actually-ready job (see
+// runScheduler's ErrCraftNotComplete handling) is pushed before its next
+// attempt.
+const rescheduleDelay = time.Second
+
+// scheduledJob is one command queued to run through Execute once a
+// GameService's clock reaches At.
+type scheduledJob struct {
+	At  time.Time
+	Cmd commands.Command
+}
+
+// scheduler holds commands waiting to run at a future time. add/peek/popDue
+// are safe for concurrent use; runScheduler is the sole consumer of wake and
+// done.
+type scheduler struct {
+	mu   sync.Mutex
+	jobs []scheduledJob
+
+	// wake is poked by add so runScheduler can shorten its wait when a job
+	// earlier than the one it's currently parked on arrives.
+	wake chan struct{}
+	done chan struct{}
+
+	// stopped is closed by runScheduler just before it returns, so Close can
+	// wait for it to actually stop (and release any clock.Timer it holds)
+	// instead of merely signaling done and moving on.
+	stopped chan struct{}
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// add inserts job in time order and pokes runScheduler.
+func (sch *scheduler) add(job scheduledJob) {
+	sch.mu.Lock()
+	sch.jobs = append(sch.jobs, job)
+	sort.Slice(sch.jobs, func(i, j int) bool { return sch.jobs[i].At.Before(sch.jobs[j].At) })
+	sch.mu.Unlock()
+
+	select {
+	case sch.wake <- struct{}{}:
+	default:
+	}
+}
+
+// peek returns the earliest job without removing it.
+func (sch *scheduler) peek() (scheduledJob, bool) {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	if len(sch.jobs) == 0 {
+		return scheduledJob{}, false
+	}
+	return sch.jobs[0], true
+}
+
+// popDue removes and returns the earliest job if it is not after now.
+func (sch *scheduler) popDue(now time.Time) (scheduledJob, bool) {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	if len(sch.jobs) == 0 || sch.jobs[0].At.After(now) {
+		return scheduledJob{}, false
+	}
+	job := sch.jobs[0]
+	sch.jobs = sch.jobs[1:]
+	return job, true
+}
+
+func (sch *scheduler) close() {
+	close(sch.done)
+}
+
+// withNewCommandID rebuilds cmd with id as its CommandID, for the
+// runScheduler retry path: a retried attempt is a distinct logical command
+// from the one that found the craft job not yet complete, so it must not
+// collide with that attempt's cached idempotent Result (see applyAtLocked).
+func withNewCommandID(cmd commands.Command, id string) commands.Command {
+	switch c := cmd.(type) {
+	case *commands.Settle:
+		return &commands.Settle{CommandIDValue: id}
+	case commands.UnlockComponentCrafting:
+		return commands.UnlockComponentCrafting{CommandIDValue: id}
+	case commands.CraftComponent:
+		return commands.CraftComponent{CommandIDValue: id}
+	case *commands.ClaimCraftedComponent:
+		return &commands.ClaimCraftedComponent{CommandIDValue: id, SlotIndex: c.SlotIndex}
+	case commands.CancelCraft:
+		return commands.CancelCraft{CommandIDValue: id, SlotIndex: c.SlotIndex}
+	default:
+		return cmd
+	}
+}
+
+// ScheduleAt queues cmd to run through Execute once the service's clock
+// reaches t, or as soon as possible after if t has already passed. It
+// returns ErrClosed if called after Close.
+func (s *GameService) ScheduleAt(t time.Time, cmd commands.Command) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scheduleLocked(t, cmd)
+}
+
+// scheduleLocked is ScheduleAt's body, usable by callers that already hold
+// s.mu (the CraftComponent auto-claim hook in applyAtLocked). Callers must
+// hold s.mu.
+func (s *GameService) scheduleLocked(t time.Time, cmd commands.Command) error {
+	if atomic.LoadUint32(&s.closed) == 1 {
+		return ErrClosed
+	}
+	if s.persistence != nil {
+		rec := walRecord{
+			Scheduled:   true,
+			CommandName: cmd.Name(),
+			CommandID:   cmd.CommandID(),
+			At:          t,
+		}
+		switch c := cmd.(type) {
+		case *commands.ClaimCraftedComponent:
+			rec.SlotIndex = c.SlotIndex
+		case commands.CancelCraft:
+			rec.SlotIndex = c.SlotIndex
+		}
+		if err := s.persistence.append(rec); err != nil {
+			return err
+		}
+	}
+	s.sched.add(scheduledJob{At: t, Cmd: cmd})
+	return nil
+}
+
+// reschedule is like scheduleLocked but acquires s.mu itself, for use by
+// runScheduler which never holds it between jobs.
+func (s *GameService) reschedule(t time.Time, cmd commands.Command) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scheduleLocked(t, cmd)
+}
+
+// runScheduler parks on a clock.Timer for the earliest scheduled job and
+// runs it through scheduledExecutor once due. A job that turns out not to
+// actually be ready (ErrCraftNotComplete, from clock skew between when it was
+// scheduled and when its timer fired, or ErrSchedulerUnavailable, from a
+// clustered GameService's executor finding this node isn't the Raft leader)
+// is re-queued with a fresh CommandID after rescheduleDelay rather than
+// dropped.
+func (s *GameService) runScheduler() {
+	defer close(s.sched.stopped)
+
+	for {
+		job, ok := s.sched.peek()
+		if !ok {
+			select {
+			case <-s.sched.wake:
+				continue
+			case <-s.sched.done:
+				return
+			}
+		}
+
+		wait := job.At.Sub(s.liveClock.Now())
+		if wait < 0 {
+			wait = 0
+		}
+		timer := s.liveClock.NewTimer(wait)
+
+		select {
+		case <-timer.C():
+			due, ok := s.sched.popDue(s.liveClock.Now())
+			if !ok {
+				// A newer, earlier job raced in between peek and the timer
+				// firing; loop and recompute against the real earliest.
+				continue
+			}
+			s.mu.Lock()
+			exec := s.scheduledExecutor
+			s.mu.Unlock()
+			if _, err := exec(due.Cmd); err == ErrCraftNotComplete || err == ErrSchedulerUnavailable {
+				retryID := s.nextLocalCommandID("retry_" + due.Cmd.Name())
+				_ = s.reschedule(s.liveClock.Now().Add(rescheduleDelay), withNewCommandID(due.Cmd, retryID))
+			}
+		case <-s.sched.wake:
+			timer.Stop()
+		case <-s.sched.done:
+			timer.Stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"scraps/internal/clock"
+	"scraps/internal/commands"
+	"scraps/internal/config"
+)
+
+func TestSchedulerAddOrdersByTime(t *testing.T) {
+	sch := newScheduler()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sch.add(scheduledJob{At: base.Add(2 * time.Second), Cmd: commands.CancelCraft{CommandIDValue: "b"}})
+	sch.add(scheduledJob{At: base.Add(1 * time.Second), Cmd: commands.CancelCraft{CommandIDValue: "a"}})
+
+	job, ok := sch.peek()
+	if !ok || job.Cmd.CommandID() != "a" {
+		t.Fatalf("expected the earlier job first, got %+v", job)
+	}
+}
+
+func TestSchedulerPopDueOnlyReturnsDueJobs(t *testing.T) {
+	sch := newScheduler()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sch.add(scheduledJob{At: base.Add(time.Second), Cmd: commands.CancelCraft{CommandIDValue: "a"}})
+
+	if _, ok := sch.popDue(base); ok {
+		t.Fatalf("expected no job due before its time")
+	}
+	job, ok := sch.popDue(base.Add(time.Second))
+	if !ok || job.Cmd.CommandID() != "a" {
+		t.Fatalf("expected job a due at its own time, got %+v, %v", job, ok)
+	}
+	if _, ok := sch.peek(); ok {
+		t.Fatalf("expected popDue to remove the job")
+	}
+}
+
+func TestCraftComponentAutoClaimsOnFinish(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	cfg := config.Default()
+	svc := NewGameService(cfg, clk, start)
+	defer svc.Close()
+
+	clk.Advance(20 * time.Second)
+	if err := svc.UnlockComponentCrafting(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("craft: %v", err)
+	}
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Duration(cfg.CraftDurationSecs) * time.Second)
+
+	deadline := time.Now().Add(time.Second)
+	for svc.GetState().Components == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the finished craft to be auto-claimed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	got := svc.GetState()
+	if got.Components != 1 {
+		t.Fatalf("expected Components 1 got %d", got.Components)
+	}
+	if len(got.ActiveCrafts) != 0 {
+		t.Fatalf("expected ActiveCrafts cleared by auto-claim")
+	}
+}
+
+func TestPromotedPendingCraftAutoClaimsOnFinish(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	cfg := config.Default()
+	cfg.MaxPendingCrafts = 1
+	svc := NewGameService(cfg, clk, start)
+	defer svc.Close()
+
+	svc.mu.Lock()
+	svc.state.CraftingUnlocked = true
+	svc.state.Scrap = 20
+	svc.mu.Unlock()
+
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("first craft: %v", err)
+	}
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("queued craft: %v", err)
+	}
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Duration(cfg.CraftDurationSecs) * time.Second)
+
+	deadline := time.Now().Add(time.Second)
+	for svc.GetState().Components == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the first craft to be auto-claimed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The claim above promoted the queued craft into the freed slot. It
+	// needs its own auto-claim scheduled just like a directly-started craft
+	// gets (see startPendingCraftLocked), or it would finish but never get
+	// claimed.
+	clk.BlockUntil(1)
+	clk.Advance(time.Duration(cfg.CraftDurationSecs) * time.Second)
+
+	deadline = time.Now().Add(time.Second)
+	for svc.GetState().Components == 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the promoted craft to be auto-claimed too")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	got := svc.GetState()
+	if got.Components != 2 {
+		t.Fatalf("expected Components 2 got %d", got.Components)
+	}
+	if len(got.ActiveCrafts) != 0 {
+		t.Fatalf("expected ActiveCrafts cleared by auto-claim")
+	}
+}
+
+func TestScheduleAtRunsCommandWhenDue(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+	defer svc.Close()
+
+	if err := svc.ScheduleAt(start.Add(5*time.Second), &commands.Settle{CommandIDValue: "scheduled-settle"}); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	clk.BlockUntil(1)
+	clk.Advance(5 * time.Second)
+
+	deadline := time.Now().Add(time.Second)
+	for svc.GetState().Scrap == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the scheduled Settle to have run")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := svc.GetState().Scrap; got != 5 {
+		t.Fatalf("expected scrap 5 got %d", got)
+	}
+}
+
+func TestScheduleAtRetriesClaimUntilCraftCompletes(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	cfg := config.Default()
+	svc := NewGameService(cfg, clk, start)
+	defer svc.Close()
+
+	clk.Advance(20 * time.Second)
+	if err := svc.UnlockComponentCrafting(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("craft: %v", err)
+	}
+
+	// Simulate clock skew: schedule an extra claim attempt a second before
+	// the craft actually finishes. It should be re-queued rather than
+	// dropped, and the component should still only be granted once.
+	if err := svc.ScheduleAt(start.Add(20*time.Second).Add(time.Duration(cfg.CraftDurationSecs-1)*time.Second), &commands.ClaimCraftedComponent{CommandIDValue: "early-claim"}); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Duration(cfg.CraftDurationSecs-1) * time.Second)
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+
+	deadline := time.Now().Add(time.Second)
+	for svc.GetState().Components == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the craft to eventually be claimed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := svc.GetState().Components; got != 1 {
+		t.Fatalf("expected exactly 1 component got %d", got)
+	}
+}
+
+func TestScheduledJobPersistsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	cfg := config.Default()
+	cfg.SnapshotEveryNEvents = 0
+
+	svc, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if err := svc.ScheduleAt(start.Add(10*time.Second), &commands.Settle{CommandIDValue: "scheduled-settle"}); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := OpenGameService(cfg, clk, start, dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	clk.BlockUntil(1)
+	clk.Advance(10 * time.Second)
+
+	deadline := time.Now().Add(time.Second)
+	for reopened.GetState().Scrap == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the reloaded scheduled job to still run")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := reopened.GetState().Scrap; got != 10 {
+		t.Fatalf("expected scrap 10 got %d", got)
+	}
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"scraps/internal/clock"
@@ -14,18 +17,95 @@ import (
 
 // GameService provides the concurrency-safe game API.
 type GameService struct {
-	mu  sync.Mutex
-	cfg config.Config
-	clock clock.Clock
-	state domain.State
+	mu            sync.Mutex
+	cfg           config.Config
+	clock         clock.Clock
+	state         domain.State
 	eventSequence int64
-	events        []events.Event
+
+	// persistence is nil for a plain in-memory service (see NewGameService).
+	persistence *persistence
+
+	// liveClock is the clock supplied at construction time. Execute briefly
+	// swaps the mu-guarded clock field for a fixedClock while applying a
+	// command; liveClock never changes, so callers that need a stable Clock
+	// without holding mu (e.g. RunAutoSettleLoop) read this instead.
+	liveClock clock.Clock
+
+	bus *events.EventBus
+
+	// proposals feeds the single applier goroutine started by NewGameService;
+	// Propose is the only sender, runApplier the only receiver. Closed by
+	// Close, guarded by closed below so Propose never sends on a closed
+	// channel.
+	proposals chan proposal
+
+	// proposeMu guards closed and serializes Propose's channel send against
+	// Close. It is deliberately separate from mu: Propose's send on
+	// proposals can block until runApplier (service.go's runApplier) is
+	// ready to receive, and runApplier needs mu to apply the proposal it
+	// just received before looping back for the next one. Holding mu across
+	// Propose's send would let a second Propose call, having won the mutex
+	// race right after that rendezvous, block runApplier's Lock() forever
+	// while itself blocking on the send under the same lock — a permanent
+	// deadlock.
+	proposeMu sync.Mutex
+
+	// closed is read under either mu (scheduleLocked/ScheduleAt) or proposeMu
+	// (Propose/Close), so it must be an atomic flag rather than a plain bool
+	// guarded by just one of them.
+	closed uint32
+
+	// idempotent caches the Result of every CommandID this service has
+	// applied, so a client that retries a Propose/Execute call over an
+	// unreliable transport gets the original outcome replayed instead of the
+	// command running twice. It is never evicted: callers are expected to
+	// use a fresh CommandID per logical command (see nextLocalCommandID).
+	idempotent map[string]Result
+
+	// localCmdSeq generates unique CommandIDs for the convenience methods
+	// (Settle, CraftComponent, ...), each of which must look like a distinct
+	// command to the idempotency cache even though they're called with no
+	// caller-supplied ID.
+	localCmdSeq uint64
+
+	// sched holds commands queued via ScheduleAt (including the CraftComponent
+	// auto-claim hook in applyAtLocked) for runScheduler to execute once due.
+	sched *scheduler
+
+	// scheduledExecutor runs a job once runScheduler decides it's due. It
+	// defaults to Execute (apply locally, right away). A clustered
+	// GameService (see internal/cluster) overrides it via
+	// SetScheduledExecutor to re-propose the command through Raft instead,
+	// since every node's own scheduler independently queues the same job
+	// (FSM.Apply runs identically everywhere) and applying it locally on
+	// each node would mutate state outside consensus. Guarded by mu.
+	scheduledExecutor func(commands.Command) (Result, error)
 }
 
+// proposal is one command queued on GameService.proposals for the applier
+// goroutine to run, along with the channel its Result is delivered on.
+type proposal struct {
+	cmd   commands.Command
+	reply chan Result
+}
+
+// eventBusRingSize bounds how many recently published events a GameService's
+// EventBus keeps for Events().Replay.
+const eventBusRingSize = 256
+
+// subscribeBufferSize bounds the channel Subscribe returns, and the
+// underlying live feed it drains from the EventBus.
+const subscribeBufferSize = 32
+
 // Result is the outcome of executing a command.
 type Result struct {
 	State  domain.State
 	Events []events.Event
+
+	// Err mirrors the error Execute/ApplyAt return alongside Result, carried
+	// on Result itself so Propose's single-channel reply can convey it too.
+	Err error
 }
 
 var (
@@ -35,23 +115,101 @@ var (
 	ErrAlreadyUnlocked   = errors.New("already unlocked")
 	// ErrCraftingLocked indicates crafting has not been unlocked yet.
 	ErrCraftingLocked    = errors.New("crafting locked")
-	// ErrCraftInProgress indicates a craft job is already active.
+	// ErrCraftInProgress indicates a craft job is already active and the
+	// service's config has no room to queue another (MaxParallelCrafts <= 1
+	// and MaxPendingCrafts == 0).
 	ErrCraftInProgress   = errors.New("craft already in progress")
+	// ErrNoCraftSlots indicates every craft slot and pending queue entry is
+	// already in use.
+	ErrNoCraftSlots      = errors.New("no craft slots available")
 	// ErrNoActiveCraft indicates there is no active craft job.
 	ErrNoActiveCraft     = errors.New("no active craft")
 	// ErrCraftNotComplete indicates the craft job has not finished yet.
 	ErrCraftNotComplete  = errors.New("craft not complete")
+	// ErrPersistenceDisabled indicates a persistence-only method was called
+	// on a GameService created without a persistence directory.
+	ErrPersistenceDisabled = errors.New("persistence disabled")
+	// ErrClosed indicates Propose or Execute was called after Close.
+	ErrClosed = errors.New("service: closed")
+	// ErrSchedulerUnavailable is returned by a scheduledExecutor (see
+	// SetScheduledExecutor) to indicate a due job could not be run right now
+	// but should be retried later, rather than treated as a genuine command
+	// failure. A clustered GameService's executor returns this when the
+	// local node isn't the Raft leader.
+	ErrSchedulerUnavailable = errors.New("service: scheduler unavailable")
 )
 
 // NewGameService initializes a new game service with empty state.
 func NewGameService(cfg config.Config, clk clock.Clock, startTime time.Time) *GameService {
-	return &GameService{
-		cfg: cfg,
-		clock: clk,
+	s := &GameService{
+		cfg:        cfg,
+		clock:      clk,
+		liveClock:  clk,
+		bus:        events.NewEventBus(eventBusRingSize),
+		proposals:  make(chan proposal),
+		idempotent: make(map[string]Result),
+		sched:      newScheduler(),
 		state: domain.State{
 			LastSettledAt: startTime,
 		},
 	}
+	s.scheduledExecutor = s.Execute
+	go s.runApplier()
+	go s.runScheduler()
+	return s
+}
+
+// SetScheduledExecutor overrides how runScheduler runs a due job. Callers
+// must set this immediately after NewGameService returns, before proposing
+// or scheduling any command, since runScheduler reads it under mu as soon as
+// a job comes due.
+func (s *GameService) SetScheduledExecutor(exec func(commands.Command) (Result, error)) {
+	s.mu.Lock()
+	s.scheduledExecutor = exec
+	s.mu.Unlock()
+}
+
+// nextLocalCommandID returns a CommandID unique to this service instance, for
+// convenience methods (Settle, CraftComponent, ...) that don't take a
+// caller-supplied CommandID but must still look like distinct commands to
+// the idempotency cache in applyAtLocked.
+func (s *GameService) nextLocalCommandID(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, atomic.AddUint64(&s.localCmdSeq, 1))
+}
+
+// runApplier is the single goroutine that drains proposals and runs them
+// through applyAtLocked, so concurrent Propose callers are serialized the
+// same way concurrent Execute callers always were via s.mu, just via a
+// channel instead. It exits once Close closes s.proposals.
+func (s *GameService) runApplier() {
+	for p := range s.proposals {
+		s.mu.Lock()
+		result, _ := s.applyAtLocked(p.cmd, s.clock.Now())
+		s.mu.Unlock()
+		p.reply <- result
+	}
+}
+
+// Propose enqueues cmd for the applier goroutine and returns a channel that
+// receives its Result once applied. It returns ErrClosed if called after
+// Close. If cmd.CommandID() has already been applied, the applier replays
+// the cached Result instead of running cmd again (see the idempotent field).
+func (s *GameService) Propose(cmd commands.Command) (<-chan Result, error) {
+	s.proposeMu.Lock()
+	defer s.proposeMu.Unlock()
+	if atomic.LoadUint32(&s.closed) == 1 {
+		return nil, ErrClosed
+	}
+	reply := make(chan Result, 1)
+	s.proposals <- proposal{cmd: cmd, reply: reply}
+	return reply, nil
+}
+
+// Events returns the service's event bus. Subscribers can filter by
+// events.EventType, and Replay lets a newly-connected subscriber catch up on
+// events published since a given ID before it started receiving live ones.
+func (s *GameService) Events() *events.EventBus {
+	return s.bus
 }
 
 // GetState returns a snapshot of the current state.
@@ -61,10 +219,19 @@ func (s *GameService) GetState() domain.State {
 	return s.snapshotLocked()
 }
 
+// GetStateAndEventSequence is GetState plus the event sequence the returned
+// state was current as of. It is used by replicated/snapshotting callers
+// (see internal/cluster) that must restore both together via RestoreState.
+func (s *GameService) GetStateAndEventSequence() (domain.State, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked(), s.eventSequence
+}
+
 // Settle mints scrap based on whole seconds elapsed since last settlement.
 func (s *GameService) Settle() int64 {
 	command := &commands.Settle{
-		CommandIDValue: "settle",
+		CommandIDValue: s.nextLocalCommandID("settle"),
 	}
 	_, _ = s.Execute(command)
 	return int64(command.MintedScrap)
@@ -73,7 +240,7 @@ func (s *GameService) Settle() int64 {
 // UnlockComponentCrafting unlocks component crafting and deducts the cost.
 func (s *GameService) UnlockComponentCrafting() error {
 	command := commands.UnlockComponentCrafting{
-		CommandIDValue: "unlock_component_crafting",
+		CommandIDValue: s.nextLocalCommandID("unlock_component_crafting"),
 	}
 	_, err := s.Execute(command)
 	return err
@@ -82,100 +249,347 @@ func (s *GameService) UnlockComponentCrafting() error {
 // CraftComponent starts a single craft job and deducts scrap immediately.
 func (s *GameService) CraftComponent() error {
 	command := commands.CraftComponent{
-		CommandIDValue: "craft_component",
+		CommandIDValue: s.nextLocalCommandID("craft_component"),
 	}
 	_, err := s.Execute(command)
 	return err
 }
 
-// ClaimCraftedComponent claims a finished craft job and grants one component.
+// ClaimCraftedComponent claims the first finished craft job and grants one
+// component. Use Execute directly with a ClaimCraftedComponent command for
+// control over which slot is claimed.
 func (s *GameService) ClaimCraftedComponent() (int64, error) {
 	command := &commands.ClaimCraftedComponent{
-		CommandIDValue: "claim_crafted_component",
+		CommandIDValue: s.nextLocalCommandID("claim_crafted_component"),
+		SlotIndex:      -1,
 	}
 	_, err := s.Execute(command)
 	return int64(command.ComponentsGained), err
 }
 
-// CancelCraft cancels an active craft job and refunds its scrap cost.
+// CancelCraft cancels an active craft job and refunds its scrap cost. Use
+// Execute directly with a CancelCraft command for control over which slot is
+// canceled.
 func (s *GameService) CancelCraft() error {
 	command := commands.CancelCraft{
-		CommandIDValue: "cancel_craft",
+		CommandIDValue: s.nextLocalCommandID("cancel_craft"),
+		SlotIndex:      -1,
 	}
 	_, err := s.Execute(command)
 	return err
 }
 
-// ListEvents returns events after the given ID, up to limit entries.
-func (s *GameService) ListEvents(sinceID int64, limit int) []events.Event {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// RunAutoSettleLoop calls Settle every interval until ctx is canceled. It is
+// driven entirely through the service's clock, so a test can pass a
+// clock.FakeClock, call BlockUntil(1) to know the loop has parked on its
+// timer, then Advance it and assert on the result instead of racing a real
+// time.Sleep.
+func (s *GameService) RunAutoSettleLoop(ctx context.Context, interval time.Duration) {
+	timer := s.liveClock.NewTimer(interval)
+	defer timer.Stop()
 
-	var filtered []events.Event
-	for _, ev := range s.events {
-		if ev.ID <= sinceID {
-			continue
-		}
-		filtered = append(filtered, ev)
-		if limit > 0 && len(filtered) >= limit {
-			break
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+			s.Settle()
+			timer = s.liveClock.NewTimer(interval)
 		}
 	}
+}
+
+// ListEvents returns events after the given ID, up to limit entries. It draws
+// from the bus's bounded ring buffer (see eventBusRingSize), not the full
+// history, so sinceID values older than the ring's retention return fewer
+// events than were actually published.
+func (s *GameService) ListEvents(sinceID int64, limit int) []events.Event {
+	filtered := s.bus.Replay(sinceID)
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
 
 	out := make([]events.Event, len(filtered))
 	copy(out, filtered)
 	return out
 }
 
-// Execute runs a command and returns the resulting state snapshot.
+// Subscribe returns a channel that first delivers every buffered event after
+// sinceID, then delivers new ones live as Execute/ApplyAt produce them, so a
+// caller never misses an event published between catching up and going live.
+// The buffered events are written to out before Subscribe returns, so a
+// caller that reads out immediately is guaranteed to see them rather than
+// racing a goroutine that forwards them asynchronously. This works because
+// the historical Replay and the live registration below both happen while
+// s.mu is held, the same lock Execute holds while publishing to the bus:
+// either a given Execute call is captured by the historical read or its
+// publish lands on the live subscription, never both and never neither. The
+// returned CancelFunc stops delivery and releases the channel.
+func (s *GameService) Subscribe(sinceID int64) (<-chan events.Event, events.CancelFunc) {
+	s.mu.Lock()
+	live, cancelLive := s.bus.Subscribe("", subscribeBufferSize)
+	buffered := s.bus.Replay(sinceID)
+	s.mu.Unlock()
+
+	out := make(chan events.Event, subscribeBufferSize+len(buffered))
+	for _, ev := range buffered {
+		out <- ev
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case ev, ok := <-live:
+				if !ok {
+					return
+				}
+				select {
+				case out <- ev:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	cancel := func() {
+		cancelLive()
+		close(done)
+	}
+	return out, cancel
+}
+
+// RestoreState replaces the in-memory state and event sequence wholesale,
+// without touching persistence. It is used by replicated/snapshot-restoring
+// callers (see internal/cluster) that need to seed a node from a foreign
+// snapshot rather than from this service's own history. The idempotent
+// cache is cleared, since a command it remembers may predate the snapshot
+// and no longer reflect this node's history at all.
+func (s *GameService) RestoreState(state domain.State, eventSequence int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	s.eventSequence = eventSequence
+	s.idempotent = make(map[string]Result)
+}
+
+// Snapshot writes the current state to disk atomically and truncates the
+// write-ahead log to only the entries applied after it. It returns
+// ErrPersistenceDisabled if the service was not opened with a persistence
+// directory.
+func (s *GameService) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.persistence == nil {
+		return ErrPersistenceDisabled
+	}
+	return s.persistence.snapshot(s.state, s.eventSequence)
+}
+
+// Compact writes a fresh snapshot of the current state and truncates the
+// write-ahead log to empty, exactly as Snapshot does. Since this
+// service keeps its WAL as a single unsegmented file rather than etcd-style
+// segments, compaction and snapshotting are the same on-disk operation; the
+// separate name documents the caller's intent to reclaim disk space rather
+// than to checkpoint state. It returns ErrPersistenceDisabled if the service
+// was not opened with a persistence directory.
+func (s *GameService) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.persistence == nil {
+		return ErrPersistenceDisabled
+	}
+	return s.persistence.snapshot(s.state, s.eventSequence)
+}
+
+// Close stops the applier and scheduler goroutines and flushes and releases
+// any persistence resources held by the service. Persistence cleanup is a
+// no-op for a service created without a persistence directory. Close is
+// idempotent.
+func (s *GameService) Close() error {
+	s.proposeMu.Lock()
+	wasClosed := atomic.LoadUint32(&s.closed) == 1
+	if !wasClosed {
+		atomic.StoreUint32(&s.closed, 1)
+		close(s.proposals)
+		s.sched.close()
+	}
+	s.proposeMu.Unlock()
+
+	// Wait for runScheduler to actually stop (releasing any clock.Timer it
+	// holds) outside s.mu: it may currently be inside Execute, which needs
+	// s.mu itself, so holding the lock here would deadlock against it.
+	if !wasClosed {
+		<-s.sched.stopped
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.persistence == nil {
+		return nil
+	}
+	return s.persistence.close()
+}
+
+// Execute runs a command and returns the resulting state snapshot. It is a
+// synchronous wrapper around Propose: it enqueues cmd on the applier
+// goroutine and blocks for its Result. If the service was opened with
+// persistence enabled, the command is durably logged to the write-ahead log
+// before state is mutated.
 func (s *GameService) Execute(cmd commands.Command) (Result, error) {
+	reply, err := s.Propose(cmd)
+	if err != nil {
+		return Result{}, err
+	}
+	result := <-reply
+	return result, result.Err
+}
+
+// ApplyAt executes cmd using at as the authoritative "now" instead of reading
+// the configured clock. It exists so a replicated caller (see
+// internal/cluster) can apply a command identically on every node: the
+// leader captures its own clock.Now() once and every follower's FSM.Apply
+// replays with that same instant.
+func (s *GameService) ApplyAt(cmd commands.Command, at time.Time) (Result, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.applyAtLocked(cmd, at)
+}
+
+func (s *GameService) applyAtLocked(cmd commands.Command, now time.Time) (Result, error) {
+	if cached, ok := s.idempotent[cmd.CommandID()]; ok {
+		return cached, cached.Err
+	}
+
+	nextID := s.eventSequence + 1
+
+	if s.persistence != nil {
+		rec := walRecord{
+			EventID:     nextID,
+			CommandName: cmd.Name(),
+			CommandID:   cmd.CommandID(),
+			At:          now,
+		}
+		switch c := cmd.(type) {
+		case *commands.ClaimCraftedComponent:
+			rec.SlotIndex = c.SlotIndex
+		case commands.CancelCraft:
+			rec.SlotIndex = c.SlotIndex
+		}
+		if err := s.persistence.append(rec); err != nil {
+			return Result{}, fmt.Errorf("persistence: append wal: %w", err)
+		}
+	}
+
+	// Pin the clock to the instant logged above so the mutation below reads
+	// the exact same "now" a WAL replay (or a raft follower) will use,
+	// keeping apply deterministic.
+	liveClock := s.clock
+	s.clock = fixedClock(now)
+	activeCraftsBefore := len(s.state.ActiveCrafts)
+	evType, data, err := s.applyLocked(cmd)
+	s.clock = liveClock
 
-	var result Result
-	var err error
-	var eventsList []events.Event
+	if _, ok := cmd.(commands.CraftComponent); ok && err == nil && len(s.state.ActiveCrafts) > activeCraftsBefore {
+		slot := len(s.state.ActiveCrafts) - 1
+		claimCmd := &commands.ClaimCraftedComponent{
+			CommandIDValue: s.nextLocalCommandID("auto_claim"),
+			SlotIndex:      slot,
+		}
+		if scheduleErr := s.scheduleLocked(s.state.ActiveCrafts[slot].FinishesAt, claimCmd); scheduleErr != nil {
+			return Result{}, fmt.Errorf("persistence: schedule auto-claim: %w", scheduleErr)
+		}
+	}
+
+	s.eventSequence = nextID
+	eventItem := events.Event{
+		ID:        s.eventSequence,
+		At:        now,
+		CommandID: cmd.CommandID(),
+		Type:      evType,
+		Data:      data,
+	}
+	s.bus.Publish(eventItem)
 
+	if s.persistence != nil {
+		if serr := s.maybeAutoSnapshotLocked(); serr != nil {
+			return Result{}, fmt.Errorf("persistence: auto snapshot: %w", serr)
+		}
+	}
+
+	result := Result{
+		State:  s.snapshotLocked(),
+		Events: []events.Event{eventItem},
+		Err:    err,
+	}
+	s.idempotent[cmd.CommandID()] = result
+	return result, err
+}
+
+// applyLocked mutates state for cmd and reports the typed event it produced.
+// On error, the event type still names the attempted command (so callers can
+// tell what failed) but Data is nil since the mutation never happened.
+// Callers must hold s.mu.
+func (s *GameService) applyLocked(cmd commands.Command) (events.EventType, any, error) {
 	switch command := cmd.(type) {
 	case commands.SyncState:
 		s.settleLocked()
+		return events.EventTypeScrapSettled, nil, nil
 	case *commands.Settle:
 		command.MintedScrap = s.settleLocked()
+		return events.EventTypeScrapSettled, events.ScrapSettledData{Minted: command.MintedScrap}, nil
 	case commands.UnlockComponentCrafting:
 		s.settleLocked()
-		err = s.unlockComponentCraftingLocked()
+		if err := s.unlockComponentCraftingLocked(); err != nil {
+			return events.EventType(cmd.Name()), nil, err
+		}
+		return events.EventTypeCraftingUnlocked, events.CraftingUnlockedData{Cost: s.cfg.CraftComponentTechnologyCost}, nil
 	case commands.CraftComponent:
 		s.settleLocked()
-		err = s.craftComponentLocked()
+		slot, err := s.craftComponentLocked()
+		if err != nil {
+			return events.EventType(cmd.Name()), nil, err
+		}
+		if slot < 0 {
+			// Queued as pending rather than started: no StartedAt/FinishesAt yet.
+			return events.EventTypeComponentCraftStarted, events.ComponentCraftStartedData{
+				ScrapCost: s.cfg.CraftComponentCost,
+			}, nil
+		}
+		ac := s.state.ActiveCrafts[slot]
+		return events.EventTypeComponentCraftStarted, events.ComponentCraftStartedData{
+			ScrapCost:  ac.ScrapCost,
+			StartedAt:  ac.StartedAt,
+			FinishesAt: ac.FinishesAt,
+		}, nil
 	case *commands.ClaimCraftedComponent:
-		var gained uint64
-		gained, err = s.claimCraftedComponentLocked()
+		gained, err := s.claimCraftedComponentLocked(command.SlotIndex)
 		command.ComponentsGained = gained
+		if err != nil {
+			return events.EventType(cmd.Name()), nil, err
+		}
+		return events.EventTypeComponentCraftClaimed, events.ComponentCraftClaimedData{ComponentsGained: gained}, nil
 	case commands.CancelCraft:
-		err = s.cancelCraftLocked()
-	}
-
-	s.eventSequence++
-	eventItem := events.Event{
-		ID:        s.eventSequence,
-		At:        s.clock.Now(),
-		CommandID: cmd.CommandID(),
-		Type:      events.EventType(cmd.Name()),
-		Data:      nil,
+		refund, err := s.cancelCraftLocked(command.SlotIndex)
+		if err != nil {
+			return events.EventType(cmd.Name()), nil, err
+		}
+		return events.EventTypeComponentCraftCanceled, events.ComponentCraftCanceledData{RefundedScrap: refund}, nil
 	}
-	s.events = append(s.events, eventItem)
-	eventsList = append(eventsList, eventItem)
-
-	result.State = s.snapshotLocked()
-	result.Events = eventsList
-	return result, err
+	return events.EventType(cmd.Name()), nil, nil
 }
 
 func (s *GameService) snapshotLocked() domain.State {
 	snap := s.state
-	if s.state.ActiveCraft != nil {
-		ac := *s.state.ActiveCraft
-		snap.ActiveCraft = &ac
+	if s.state.ActiveCrafts != nil {
+		snap.ActiveCrafts = append([]domain.CraftJob(nil), s.state.ActiveCrafts...)
 	}
 	return snap
 }
@@ -207,46 +621,147 @@ func (s *GameService) unlockComponentCraftingLocked() error {
 	return nil
 }
 
-func (s *GameService) craftComponentLocked() error {
+// maxParallelCraftsLocked treats a zero-value config (e.g. a Config built
+// without Default()) the same as 1, preserving the original single-slot
+// behavior rather than rejecting every craft outright.
+func (s *GameService) maxParallelCraftsLocked() uint64 {
+	if s.cfg.MaxParallelCrafts == 0 {
+		return 1
+	}
+	return s.cfg.MaxParallelCrafts
+}
+
+// craftComponentLocked starts a new craft job in a free slot, or queues it as
+// pending if every slot is busy but the pending queue has room. It returns
+// the index of the slot started, or -1 if the craft was queued rather than
+// started. Scrap is deducted at this point either way, since a queued
+// request has already committed its cost even though the job itself starts
+// later (see startPendingCraftLocked); deducting again at dequeue time would
+// double-charge it.
+func (s *GameService) craftComponentLocked() (int, error) {
 	if !s.state.CraftingUnlocked {
-		return ErrCraftingLocked
+		return -1, ErrCraftingLocked
 	}
-	if s.state.ActiveCraft != nil {
-		return ErrCraftInProgress
+
+	maxParallel := s.maxParallelCraftsLocked()
+	haveFreeSlot := uint64(len(s.state.ActiveCrafts)) < maxParallel
+	haveQueueRoom := s.state.PendingCrafts < s.cfg.MaxPendingCrafts
+
+	// Check capacity/in-progress before scrap so that, with the default
+	// single-slot config, a second concurrent CraftComponent racing for the
+	// only slot still gets ErrCraftInProgress rather than ErrInsufficientScrap
+	// (see TestCraftComponentConcurrent).
+	if !haveFreeSlot && !haveQueueRoom {
+		if maxParallel <= 1 && s.cfg.MaxPendingCrafts == 0 {
+			return -1, ErrCraftInProgress
+		}
+		return -1, ErrNoCraftSlots
 	}
+
 	if s.state.Scrap < s.cfg.CraftComponentCost {
-		return ErrInsufficientScrap
+		return -1, ErrInsufficientScrap
 	}
 
-	now := s.clock.Now()
-	s.state.Scrap -= s.cfg.CraftComponentCost
-	s.state.ActiveCraft = &domain.CraftJob{
-		StartedAt:  now,
-		FinishesAt: now.Add(time.Duration(s.cfg.CraftDurationSecs) * time.Second),
-		ScrapCost:  s.cfg.CraftComponentCost,
+	if haveFreeSlot {
+		now := s.clock.Now()
+		s.state.Scrap -= s.cfg.CraftComponentCost
+		s.state.ActiveCrafts = append(s.state.ActiveCrafts, domain.CraftJob{
+			StartedAt:  now,
+			FinishesAt: now.Add(time.Duration(s.cfg.CraftDurationSecs) * time.Second),
+			ScrapCost:  s.cfg.CraftComponentCost,
+		})
+		return len(s.state.ActiveCrafts) - 1, nil
 	}
-	return nil
+
+	s.state.Scrap -= s.cfg.CraftComponentCost
+	s.state.PendingCrafts++
+	return -1, nil
 }
 
-func (s *GameService) claimCraftedComponentLocked() (uint64, error) {
-	if s.state.ActiveCraft == nil {
-		return 0, ErrNoActiveCraft
+// resolveCraftSlotLocked validates slotIndex against ActiveCrafts, or picks
+// one automatically when slotIndex is negative: the first finished slot if
+// requireFinished, otherwise just the first slot. It is shared by
+// claimCraftedComponentLocked and cancelCraftLocked.
+func (s *GameService) resolveCraftSlotLocked(slotIndex int, requireFinished bool) (int, error) {
+	if slotIndex >= 0 {
+		if slotIndex >= len(s.state.ActiveCrafts) {
+			return 0, ErrNoActiveCraft
+		}
+		if requireFinished && s.clock.Now().Before(s.state.ActiveCrafts[slotIndex].FinishesAt) {
+			return 0, ErrCraftNotComplete
+		}
+		return slotIndex, nil
+	}
+
+	now := s.clock.Now()
+	for i, job := range s.state.ActiveCrafts {
+		if !requireFinished || !now.Before(job.FinishesAt) {
+			return i, nil
+		}
 	}
-	if s.clock.Now().Before(s.state.ActiveCraft.FinishesAt) {
+	if requireFinished && len(s.state.ActiveCrafts) > 0 {
 		return 0, ErrCraftNotComplete
 	}
+	return 0, ErrNoActiveCraft
+}
+
+func (s *GameService) claimCraftedComponentLocked(slotIndex int) (uint64, error) {
+	idx, err := s.resolveCraftSlotLocked(slotIndex, true)
+	if err != nil {
+		return 0, err
+	}
 
 	s.state.Components += 1
-	s.state.ActiveCraft = nil
+	if err := s.removeCraftSlotLocked(idx); err != nil {
+		return 1, err
+	}
 	return 1, nil
 }
 
-func (s *GameService) cancelCraftLocked() error {
-	if s.state.ActiveCraft == nil {
-		return ErrNoActiveCraft
+func (s *GameService) cancelCraftLocked(slotIndex int) (uint64, error) {
+	idx, err := s.resolveCraftSlotLocked(slotIndex, false)
+	if err != nil {
+		return 0, err
 	}
 
-	s.state.Scrap += s.state.ActiveCraft.ScrapCost
-	s.state.ActiveCraft = nil
-	return nil
+	refund := s.state.ActiveCrafts[idx].ScrapCost
+	s.state.Scrap += refund
+	if err := s.removeCraftSlotLocked(idx); err != nil {
+		return refund, err
+	}
+	return refund, nil
+}
+
+// removeCraftSlotLocked splices out the craft at idx and promotes a pending
+// request into the freed slot, if one is queued (see startPendingCraftLocked,
+// which also schedules the promoted craft's auto-claim).
+func (s *GameService) removeCraftSlotLocked(idx int) error {
+	s.state.ActiveCrafts = append(s.state.ActiveCrafts[:idx], s.state.ActiveCrafts[idx+1:]...)
+	return s.startPendingCraftLocked()
+}
+
+// startPendingCraftLocked promotes the next queued request into a newly
+// freed slot, if one is queued, and schedules its auto-claim the same way
+// applyAtLocked does for a craft craftComponentLocked starts directly:
+// without this, a promoted craft would finish but never get
+// claimed until some other command happened to touch its slot.
+func (s *GameService) startPendingCraftLocked() error {
+	if s.state.PendingCrafts == 0 {
+		return nil
+	}
+	s.state.PendingCrafts--
+
+	now := s.clock.Now()
+	job := domain.CraftJob{
+		StartedAt:  now,
+		FinishesAt: now.Add(time.Duration(s.cfg.CraftDurationSecs) * time.Second),
+		ScrapCost:  s.cfg.CraftComponentCost,
+	}
+	s.state.ActiveCrafts = append(s.state.ActiveCrafts, job)
+
+	claimCmd := &commands.ClaimCraftedComponent{
+		CommandIDValue: s.nextLocalCommandID("auto_claim"),
+		SlotIndex:      len(s.state.ActiveCrafts) - 1,
+	}
+	return s.scheduleLocked(job.FinishesAt, claimCmd)
 }
@@ -1,27 +1,18 @@
 package service
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
 
 	"scraps/internal/clock"
+	"scraps/internal/commands"
 	"scraps/internal/config"
 	"scraps/internal/domain"
+	"scraps/internal/events"
 )
 
-type fakeClock struct {
-	now time.Time
-}
-
-func (f *fakeClock) Now() time.Time {
-	return f.now
-}
-
-func (f *fakeClock) Advance(d time.Duration) {
-	f.now = f.now.Add(d)
-}
-
 func TestNewGameServiceInitialState(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	svc := NewGameService(config.Default(), clock.RealClock{}, start)
@@ -30,8 +21,8 @@ func TestNewGameServiceInitialState(t *testing.T) {
 	if got.Scrap != 0 || got.Components != 0 || got.CraftingUnlocked {
 		t.Fatalf("unexpected initial counters: %+v", got)
 	}
-	if got.ActiveCraft != nil {
-		t.Fatalf("expected nil ActiveCraft")
+	if got.ActiveCrafts != nil {
+		t.Fatalf("expected nil ActiveCrafts")
 	}
 	if !got.LastSettledAt.Equal(start) {
 		t.Fatalf("expected LastSettledAt %v got %v", start, got.LastSettledAt)
@@ -42,37 +33,37 @@ func TestGetStateReturnsCopy(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	svc := NewGameService(config.Default(), clock.RealClock{}, start)
 
-	original := &domain.CraftJob{
+	original := []domain.CraftJob{{
 		StartedAt:  start,
 		FinishesAt: start.Add(10 * time.Second),
 		ScrapCost:  10,
-	}
+	}}
 
 	svc.mu.Lock()
 	svc.state.Scrap = 5
-	svc.state.ActiveCraft = original
+	svc.state.ActiveCrafts = original
 	svc.mu.Unlock()
 
 	snap := svc.GetState()
 	snap.Scrap = 99
-	if snap.ActiveCraft == nil {
-		t.Fatalf("expected ActiveCraft in snapshot")
+	if len(snap.ActiveCrafts) != 1 {
+		t.Fatalf("expected ActiveCrafts in snapshot")
 	}
-	snap.ActiveCraft.ScrapCost = 999
+	snap.ActiveCrafts[0].ScrapCost = 999
 
 	svc.mu.Lock()
 	defer svc.mu.Unlock()
 	if svc.state.Scrap != 5 {
 		t.Fatalf("expected internal Scrap to remain 5 got %d", svc.state.Scrap)
 	}
-	if svc.state.ActiveCraft == nil {
-		t.Fatalf("expected internal ActiveCraft")
+	if len(svc.state.ActiveCrafts) != 1 {
+		t.Fatalf("expected internal ActiveCrafts")
 	}
-	if svc.state.ActiveCraft.ScrapCost != 10 {
-		t.Fatalf("expected internal ScrapCost to remain 10 got %d", svc.state.ActiveCraft.ScrapCost)
+	if svc.state.ActiveCrafts[0].ScrapCost != 10 {
+		t.Fatalf("expected internal ScrapCost to remain 10 got %d", svc.state.ActiveCrafts[0].ScrapCost)
 	}
-	if svc.state.ActiveCraft == snap.ActiveCraft {
-		t.Fatalf("expected deep copy of ActiveCraft")
+	if &svc.state.ActiveCrafts[0] == &snap.ActiveCrafts[0] {
+		t.Fatalf("expected deep copy of ActiveCrafts")
 	}
 }
 
@@ -98,7 +89,7 @@ func TestGetStateConcurrent(t *testing.T) {
 
 func TestSettleNoMintUnderOneSecond(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	clk.Advance(500 * time.Millisecond)
@@ -117,7 +108,7 @@ func TestSettleNoMintUnderOneSecond(t *testing.T) {
 
 func TestSettleOneSecond(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	clk.Advance(1 * time.Second)
@@ -136,7 +127,7 @@ func TestSettleOneSecond(t *testing.T) {
 
 func TestSettleTenSeconds(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	clk.Advance(10 * time.Second)
@@ -155,7 +146,7 @@ func TestSettleTenSeconds(t *testing.T) {
 
 func TestSettleTwiceWithoutAdvance(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	clk.Advance(1 * time.Second)
@@ -169,7 +160,7 @@ func TestSettleTwiceWithoutAdvance(t *testing.T) {
 
 func TestSettlePartialSecondsCarry(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	clk.Advance(1900 * time.Millisecond)
@@ -190,7 +181,7 @@ func TestSettlePartialSecondsCarry(t *testing.T) {
 
 func TestSettleConcurrent(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	clk.Advance(10 * time.Second)
@@ -216,11 +207,61 @@ func TestSettleConcurrent(t *testing.T) {
 	}
 }
 
+func TestRunAutoSettleLoopSettlesOnEachTick(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunAutoSettleLoop(ctx, time.Second)
+		close(done)
+	}()
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	clk.BlockUntil(1)
+
+	got := svc.GetState()
+	if got.Scrap != 2 {
+		t.Fatalf("expected scrap 2 after two ticks got %d", got.Scrap)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected RunAutoSettleLoop to return after cancel")
+	}
+}
+
+func TestApplyAtUsesGivenTimeNotClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	at := start.Add(5 * time.Second)
+	if _, err := svc.ApplyAt(&commands.Settle{CommandIDValue: "settle-1"}, at); err != nil {
+		t.Fatalf("expected success got %v", err)
+	}
+
+	got := svc.GetState()
+	if got.Scrap != 5 {
+		t.Fatalf("expected scrap 5 got %d", got.Scrap)
+	}
+	if !got.LastSettledAt.Equal(at) {
+		t.Fatalf("expected LastSettledAt %v got %v", at, got.LastSettledAt)
+	}
+}
+
 func TestUnlockComponentCraftingInsufficient(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	cfg := config.Default()
 	cfg.CraftComponentTechnologyCost = 100
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -236,7 +277,7 @@ func TestUnlockComponentCraftingAtCost(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	cfg := config.Default()
 	cfg.CraftComponentTechnologyCost = 100
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -260,7 +301,7 @@ func TestUnlockComponentCraftingIdempotent(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	cfg := config.Default()
 	cfg.CraftComponentTechnologyCost = 100
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -285,7 +326,7 @@ func TestUnlockComponentCraftingSettlesFirst(t *testing.T) {
 	cfg := config.Default()
 	cfg.CraftComponentTechnologyCost = 100
 	cfg.BaseScrapProduction = 1
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -302,7 +343,7 @@ func TestUnlockComponentCraftingConcurrent(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	cfg := config.Default()
 	cfg.CraftComponentTechnologyCost = 100
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -352,7 +393,7 @@ func TestUnlockComponentCraftingConcurrent(t *testing.T) {
 func TestCraftComponentLocked(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	cfg := config.Default()
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	if err := svc.CraftComponent(); err != ErrCraftingLocked {
@@ -364,7 +405,7 @@ func TestCraftComponentInsufficientScrap(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	cfg := config.Default()
 	cfg.CraftComponentCost = 10
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -382,7 +423,7 @@ func TestCraftComponentAtCost(t *testing.T) {
 	cfg := config.Default()
 	cfg.CraftComponentCost = 10
 	cfg.CraftDurationSecs = 10
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -398,34 +439,34 @@ func TestCraftComponentAtCost(t *testing.T) {
 	if got.Scrap != 0 {
 		t.Fatalf("expected scrap 0 got %d", got.Scrap)
 	}
-	if got.ActiveCraft == nil {
-		t.Fatalf("expected ActiveCraft")
+	if len(got.ActiveCrafts) != 1 {
+		t.Fatalf("expected 1 ActiveCrafts entry")
 	}
-	if !got.ActiveCraft.StartedAt.Equal(start) {
-		t.Fatalf("expected StartedAt %v got %v", start, got.ActiveCraft.StartedAt)
+	if !got.ActiveCrafts[0].StartedAt.Equal(start) {
+		t.Fatalf("expected StartedAt %v got %v", start, got.ActiveCrafts[0].StartedAt)
 	}
-	if !got.ActiveCraft.FinishesAt.Equal(start.Add(10 * time.Second)) {
-		t.Fatalf("expected FinishesAt %v got %v", start.Add(10*time.Second), got.ActiveCraft.FinishesAt)
+	if !got.ActiveCrafts[0].FinishesAt.Equal(start.Add(10 * time.Second)) {
+		t.Fatalf("expected FinishesAt %v got %v", start.Add(10*time.Second), got.ActiveCrafts[0].FinishesAt)
 	}
-	if got.ActiveCraft.ScrapCost != 10 {
-		t.Fatalf("expected ScrapCost 10 got %d", got.ActiveCraft.ScrapCost)
+	if got.ActiveCrafts[0].ScrapCost != 10 {
+		t.Fatalf("expected ScrapCost 10 got %d", got.ActiveCrafts[0].ScrapCost)
 	}
 }
 
 func TestCraftComponentInProgress(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	cfg := config.Default()
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
 	svc.state.CraftingUnlocked = true
 	svc.state.Scrap = 20
-	svc.state.ActiveCraft = &domain.CraftJob{
+	svc.state.ActiveCrafts = []domain.CraftJob{{
 		StartedAt:  start,
 		FinishesAt: start.Add(10 * time.Second),
 		ScrapCost:  10,
-	}
+	}}
 	svc.mu.Unlock()
 
 	if err := svc.CraftComponent(); err != ErrCraftInProgress {
@@ -438,7 +479,7 @@ func TestCraftComponentSettlesFirst(t *testing.T) {
 	cfg := config.Default()
 	cfg.CraftComponentCost = 10
 	cfg.BaseScrapProduction = 1
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -456,7 +497,7 @@ func TestCraftComponentConcurrent(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	cfg := config.Default()
 	cfg.CraftComponentCost = 10
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(cfg, clk, start)
 
 	svc.mu.Lock()
@@ -499,14 +540,14 @@ func TestCraftComponentConcurrent(t *testing.T) {
 	if got.Scrap != 0 {
 		t.Fatalf("expected scrap 0 got %d", got.Scrap)
 	}
-	if got.ActiveCraft == nil {
-		t.Fatalf("expected ActiveCraft")
+	if len(got.ActiveCrafts) != 1 {
+		t.Fatalf("expected 1 ActiveCrafts entry")
 	}
 }
 
 func TestClaimCraftedComponentNoActiveCraft(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	if _, err := svc.ClaimCraftedComponent(); err != ErrNoActiveCraft {
@@ -516,15 +557,15 @@ func TestClaimCraftedComponentNoActiveCraft(t *testing.T) {
 
 func TestClaimCraftedComponentNotComplete(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	svc.mu.Lock()
-	svc.state.ActiveCraft = &domain.CraftJob{
+	svc.state.ActiveCrafts = []domain.CraftJob{{
 		StartedAt:  start,
 		FinishesAt: start.Add(10 * time.Second),
 		ScrapCost:  10,
-	}
+	}}
 	svc.mu.Unlock()
 
 	clk.Advance(9 * time.Second)
@@ -535,15 +576,15 @@ func TestClaimCraftedComponentNotComplete(t *testing.T) {
 
 func TestClaimCraftedComponentAtFinish(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	svc.mu.Lock()
-	svc.state.ActiveCraft = &domain.CraftJob{
+	svc.state.ActiveCrafts = []domain.CraftJob{{
 		StartedAt:  start,
 		FinishesAt: start.Add(10 * time.Second),
 		ScrapCost:  10,
-	}
+	}}
 	svc.mu.Unlock()
 
 	clk.Advance(10 * time.Second)
@@ -555,22 +596,22 @@ func TestClaimCraftedComponentAtFinish(t *testing.T) {
 	if got.Components != 1 {
 		t.Fatalf("expected Components 1 got %d", got.Components)
 	}
-	if got.ActiveCraft != nil {
-		t.Fatalf("expected ActiveCraft cleared")
+	if len(got.ActiveCrafts) != 0 {
+		t.Fatalf("expected ActiveCrafts cleared")
 	}
 }
 
 func TestClaimCraftedComponentAfterFinish(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	svc.mu.Lock()
-	svc.state.ActiveCraft = &domain.CraftJob{
+	svc.state.ActiveCrafts = []domain.CraftJob{{
 		StartedAt:  start,
 		FinishesAt: start.Add(10 * time.Second),
 		ScrapCost:  10,
-	}
+	}}
 	svc.mu.Unlock()
 
 	clk.Advance(15 * time.Second)
@@ -581,15 +622,15 @@ func TestClaimCraftedComponentAfterFinish(t *testing.T) {
 
 func TestClaimCraftedComponentTwice(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	svc.mu.Lock()
-	svc.state.ActiveCraft = &domain.CraftJob{
+	svc.state.ActiveCrafts = []domain.CraftJob{{
 		StartedAt:  start,
 		FinishesAt: start.Add(10 * time.Second),
 		ScrapCost:  10,
-	}
+	}}
 	svc.mu.Unlock()
 
 	clk.Advance(12 * time.Second)
@@ -603,15 +644,15 @@ func TestClaimCraftedComponentTwice(t *testing.T) {
 
 func TestClaimCraftedComponentConcurrent(t *testing.T) {
 	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	clk := &fakeClock{now: start}
+	clk := clock.NewFakeClock(start)
 	svc := NewGameService(config.Default(), clk, start)
 
 	svc.mu.Lock()
-	svc.state.ActiveCraft = &domain.CraftJob{
+	svc.state.ActiveCrafts = []domain.CraftJob{{
 		StartedAt:  start,
 		FinishesAt: start.Add(10 * time.Second),
 		ScrapCost:  10,
-	}
+	}}
 	svc.mu.Unlock()
 
 	clk.Advance(10 * time.Second)
@@ -664,7 +705,354 @@ func TestClaimCraftedComponentConcurrent(t *testing.T) {
 	if got.Components != 1 {
 		t.Fatalf("expected Components 1 got %d", got.Components)
 	}
-	if got.ActiveCraft != nil {
-		t.Fatalf("expected ActiveCraft cleared")
+	if len(got.ActiveCrafts) != 0 {
+		t.Fatalf("expected ActiveCrafts cleared")
+	}
+}
+
+func TestExecuteEmitsTypedEventData(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	clk.Advance(3 * time.Second)
+	result, err := svc.Execute(&commands.Settle{CommandIDValue: "settle-1"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(result.Events) != 1 {
+		t.Fatalf("expected 1 event got %d", len(result.Events))
+	}
+	ev := result.Events[0]
+	if ev.Type != events.EventTypeScrapSettled {
+		t.Fatalf("expected EventTypeScrapSettled got %s", ev.Type)
+	}
+	data, ok := ev.Data.(events.ScrapSettledData)
+	if !ok {
+		t.Fatalf("expected ScrapSettledData got %T", ev.Data)
+	}
+	if data.Minted != 3 {
+		t.Fatalf("expected Minted 3 got %d", data.Minted)
+	}
+}
+
+func TestExecuteFailureStillEmitsAnEventWithNilData(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	result, err := svc.Execute(commands.CraftComponent{CommandIDValue: "craft-1"})
+	if err != ErrCraftingLocked {
+		t.Fatalf("expected ErrCraftingLocked got %v", err)
+	}
+	if len(result.Events) != 1 {
+		t.Fatalf("expected 1 event got %d", len(result.Events))
+	}
+	if ev := result.Events[0]; ev.Data != nil {
+		t.Fatalf("expected nil Data on a failed command got %+v", ev.Data)
+	}
+}
+
+func TestGameServiceEventsSubscribeReceivesPublishedEvent(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	sub, cancel := svc.Events().Subscribe(events.EventTypeScrapSettled, 4)
+	defer cancel()
+
+	clk.Advance(2 * time.Second)
+	if _, err := svc.Execute(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	select {
+	case ev := <-sub:
+		if ev.CommandID != "settle-1" {
+			t.Fatalf("expected event for settle-1 got %s", ev.CommandID)
+		}
+	default:
+		t.Fatalf("expected a published event")
+	}
+}
+
+func TestSubscribeDeliversBufferedThenLiveEvents(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	clk.Advance(1 * time.Second)
+	if _, err := svc.Execute(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	sub, cancel := svc.Subscribe(0)
+	defer cancel()
+
+	select {
+	case ev := <-sub:
+		if ev.CommandID != "settle-1" {
+			t.Fatalf("expected buffered event for settle-1 got %s", ev.CommandID)
+		}
+	default:
+		t.Fatalf("expected the already-applied event to be delivered first")
+	}
+
+	clk.Advance(1 * time.Second)
+	if _, err := svc.Execute(&commands.Settle{CommandIDValue: "settle-2"}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	select {
+	case ev := <-sub:
+		if ev.CommandID != "settle-2" {
+			t.Fatalf("expected live event for settle-2 got %s", ev.CommandID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the live event to arrive")
+	}
+}
+
+func TestSubscribeCancelStopsDelivery(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	sub, cancel := svc.Subscribe(0)
+	cancel()
+
+	if _, err := svc.Execute(&commands.Settle{CommandIDValue: "settle-1"}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	select {
+	case ev, ok := <-sub:
+		if ok {
+			t.Fatalf("expected no delivery after cancel, got %+v", ev)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestExecuteWithRepeatedCommandIDReplaysCachedResult(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	clk.Advance(3 * time.Second)
+	first, err := svc.Execute(&commands.Settle{CommandIDValue: "settle-1"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	clk.Advance(5 * time.Second)
+	second, err := svc.Execute(&commands.Settle{CommandIDValue: "settle-1"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if second.State.Scrap != first.State.Scrap {
+		t.Fatalf("expected repeated CommandID to replay the cached result (scrap %d) unchanged, got %d", first.State.Scrap, second.State.Scrap)
+	}
+	if got := svc.GetState().Scrap; got != first.State.Scrap {
+		t.Fatalf("expected the second Settle not to mint again, state scrap %d, want %d", got, first.State.Scrap)
+	}
+}
+
+func TestProposeAfterCloseReturnsErrClosed(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if _, err := svc.Propose(&commands.Settle{CommandIDValue: "settle-1"}); err != ErrClosed {
+		t.Fatalf("expected ErrClosed got %v", err)
+	}
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	if err := svc.Close(); err != nil {
+		t.Fatalf("first close: %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("second close: %v", err)
+	}
+}
+
+func TestProposeDeliversResultOnReturnedChannel(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(config.Default(), clk, start)
+
+	clk.Advance(4 * time.Second)
+	reply, err := svc.Propose(&commands.Settle{CommandIDValue: "settle-1"})
+	if err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+
+	select {
+	case result := <-reply:
+		if result.Err != nil {
+			t.Fatalf("expected no error got %v", result.Err)
+		}
+		if result.State.Scrap != 4 {
+			t.Fatalf("expected scrap 4 got %d", result.State.Scrap)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a result on the reply channel")
+	}
+}
+
+func TestCraftComponentStartsSecondSlotWhenParallelAllows(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.Default()
+	cfg.MaxParallelCrafts = 2
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(cfg, clk, start)
+
+	svc.mu.Lock()
+	svc.state.CraftingUnlocked = true
+	svc.state.Scrap = 20
+	svc.mu.Unlock()
+
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("first craft: %v", err)
+	}
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("second craft: %v", err)
+	}
+
+	got := svc.GetState()
+	if len(got.ActiveCrafts) != 2 {
+		t.Fatalf("expected 2 ActiveCrafts got %d", len(got.ActiveCrafts))
+	}
+	if got.Scrap != 0 {
+		t.Fatalf("expected scrap 0 got %d", got.Scrap)
+	}
+}
+
+func TestCraftComponentQueuesAsPendingWhenSlotsFull(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.Default()
+	cfg.MaxPendingCrafts = 1
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(cfg, clk, start)
+
+	svc.mu.Lock()
+	svc.state.CraftingUnlocked = true
+	svc.state.Scrap = 20
+	svc.mu.Unlock()
+
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("first craft: %v", err)
+	}
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("queued craft: %v", err)
+	}
+
+	got := svc.GetState()
+	if len(got.ActiveCrafts) != 1 {
+		t.Fatalf("expected 1 ActiveCrafts got %d", len(got.ActiveCrafts))
+	}
+	if got.PendingCrafts != 1 {
+		t.Fatalf("expected 1 PendingCrafts got %d", got.PendingCrafts)
+	}
+	if got.Scrap != 0 {
+		t.Fatalf("expected scrap 0 (deducted at enqueue) got %d", got.Scrap)
+	}
+}
+
+func TestCraftComponentReturnsErrNoCraftSlotsWhenQueueFull(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.Default()
+	cfg.MaxPendingCrafts = 1
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(cfg, clk, start)
+
+	svc.mu.Lock()
+	svc.state.CraftingUnlocked = true
+	svc.state.Scrap = 30
+	svc.mu.Unlock()
+
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("first craft: %v", err)
+	}
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("queued craft: %v", err)
+	}
+	if err := svc.CraftComponent(); err != ErrNoCraftSlots {
+		t.Fatalf("expected ErrNoCraftSlots got %v", err)
+	}
+}
+
+func TestClaimCraftedComponentBySlotIndex(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.Default()
+	cfg.MaxParallelCrafts = 2
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(cfg, clk, start)
+
+	svc.mu.Lock()
+	svc.state.CraftingUnlocked = true
+	svc.state.Scrap = 20
+	svc.state.ActiveCrafts = []domain.CraftJob{
+		{StartedAt: start, FinishesAt: start.Add(10 * time.Second), ScrapCost: 10},
+		{StartedAt: start, FinishesAt: start.Add(10 * time.Second), ScrapCost: 10},
+	}
+	svc.mu.Unlock()
+
+	clk.Advance(10 * time.Second)
+
+	command := &commands.ClaimCraftedComponent{CommandIDValue: "claim-slot-1", SlotIndex: 1}
+	if _, err := svc.Execute(command); err != nil {
+		t.Fatalf("claim slot 1: %v", err)
+	}
+
+	got := svc.GetState()
+	if len(got.ActiveCrafts) != 1 {
+		t.Fatalf("expected 1 remaining ActiveCrafts got %d", len(got.ActiveCrafts))
+	}
+	if !got.ActiveCrafts[0].FinishesAt.Equal(start.Add(10 * time.Second)) {
+		t.Fatalf("expected slot 0 to remain untouched")
+	}
+}
+
+func TestClaimCraftedComponentPromotesPendingCraft(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.Default()
+	cfg.MaxPendingCrafts = 1
+	clk := clock.NewFakeClock(start)
+	svc := NewGameService(cfg, clk, start)
+
+	svc.mu.Lock()
+	svc.state.CraftingUnlocked = true
+	svc.state.Scrap = 20
+	svc.mu.Unlock()
+
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("first craft: %v", err)
+	}
+	if err := svc.CraftComponent(); err != nil {
+		t.Fatalf("queued craft: %v", err)
+	}
+
+	clk.Advance(10 * time.Second)
+	if _, err := svc.ClaimCraftedComponent(); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	got := svc.GetState()
+	if got.PendingCrafts != 0 {
+		t.Fatalf("expected PendingCrafts 0 got %d", got.PendingCrafts)
+	}
+	if len(got.ActiveCrafts) != 1 {
+		t.Fatalf("expected the queued craft to have been promoted, got %d ActiveCrafts", len(got.ActiveCrafts))
 	}
 }